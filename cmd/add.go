@@ -30,7 +30,15 @@ provided in days or weeks, because est's auto time tracking uses customizable
 working hours which makes estimation in days or weeks confusing and error-prone.
 Split large tasks such that estimates are below 16 hours. See 'est schedule'.
 
-The start time can be in the past with -a, using the same duration syntax as -e.
+When an estimate is given, est suggests the likely actual time of the task,
+calibrated by how long your done tasks with estimates of similar size actually
+took: the median and 80th percentile. The suggestion needs at least 5 done
+tasks and doesn't change the estimate. When no estimate is given, est instead
+suggests an estimate from done tasks with similar names, see 'est help
+suggest'. Suppress suggestions with --no-suggest.
+
+The start time can be in the past with -a, using the same duration syntax as -e,
+or with --at, using an absolute time such as "9:40am" or "yesterday 4pm".
 
 Examples:
   # Add an unestimated task named "my new task".
@@ -51,6 +59,9 @@ Examples:
   # Add an estimated task and start it as of one hour ago.
   est a -e 4h -s -a 1h "this is a four hour task I started an hour ago"
 
+  # Add an estimated task and start it as of 9:40am today.
+  est a -e 2h -s --at 9:40am "the task I forgot to start this morning"
+
   # Add and start an estimated task such that multiple tasks are now started.
   est a -e 1h -sm multiple tasks started if another was already started
 
@@ -94,29 +105,25 @@ Examples:
 			}
 			ef.Tasks = append(ef.Tasks, t)
 			if addCmdStartNow {
-				startTime := applyFlagAgo(time.Now())
-				if err := doFlagMultiple(ef, globalWorkTimes, startTime); err != nil {
-					fmt.Printf("fatal: %v\n", err)
-					os.Exit(1)
-					return
-				}
-				if err := ef.Tasks.Start(globalWorkTimes, len(ef.Tasks)-1, startTime); err != nil {
+				startTime := applyTimeFlags(time.Now())
+				if err := startTask(ef, ec.WorkTimes(), len(ef.Tasks)-1, startTime); err != nil {
 					fmt.Printf("fatal: %v\n", err)
 					os.Exit(1)
 					return
 				}
 				doFlagLog(t, startTime)
-			} else if flagAgo != "" {
-				fmt.Println("fatal: cannot add new task: -a --ago flag requires -s --start")
+			} else if flagAgo != "" || flagAt != "" {
+				fmt.Println("fatal: cannot add new task: -a --ago and --at flags require -s --start")
 				os.Exit(1)
 				return
 			}
-			if err := ef.Write(); err != nil {
+			if err := ef.Write(commandDescription()); err != nil {
 				fmt.Printf("fatal: %v\n", err)
 				os.Exit(1)
 				return
 			}
 			fmt.Println(core.RenderTaskOneLineSummary(t, true))
+			doFlagSuggest(ef, t.Name(), estimate)
 		}, func() {
 			// failed to load estconfig or estfile. Err printed elsewhere.
 			os.Exit(1)
@@ -138,6 +145,8 @@ func init() {
 	addCmd.PersistentFlags().StringVarP(&flagLog, "log", "l", "", "log time worked after starting this new task")
 	addCmd.PersistentFlags().StringVarP(&flagEstimate, "estimate", "e", "", "estimate new task")
 	addCmd.PersistentFlags().StringVarP(&flagAgo, "ago", "a", "", "when used with start, start duration ago from now")
+	addCmd.PersistentFlags().StringVar(&flagAt, "at", "", "when used with start, start at this time, e.g. 9:40am or \"yesterday 4pm\"")
+	addCmd.PersistentFlags().BoolVar(&flagNoSuggest, "no-suggest", false, "don't suggest an estimate from history")
 	addCmd.PersistentFlags().BoolVarP(&addCmdStartNow, "start", "s", false, "immediately start new task")
 	rootCmd.AddCommand(addCmd)
 }
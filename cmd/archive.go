@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Archive old done and deleted tasks",
+	Long: `Archive old done and deleted tasks
+
+est archive --before <date>
+
+Move done and deleted tasks, which were done or deleted before the passed date,
+out of your estfile and into an archive file stored alongside it. A large
+estfile makes every invocation of est slower, including est-prompt.
+
+Archived tasks remain evidence for 'est schedule' and 'est howamidoing': their
+accuracy ratios are kept in your estfile. Archived tasks can be shown with
+'est ls --archived'. An archive can be reverted with 'est undo'.
+
+Examples:
+  # Archive tasks done or deleted before 2018.
+  est archive --before 2018-01-01
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if archiveFlagBefore == "" {
+			fmt.Println("usage: est archive --before <date>")
+			os.Exit(1)
+			return
+		}
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			before, err := parseDate(archiveFlagBefore, "before date", ec.WorkTimes().Location())
+			if err != nil {
+				fmt.Println("fatal: " + err.Error())
+				os.Exit(1)
+				return
+			}
+			ts, err := ef.Archive(before)
+			if err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			if len(ts) == 0 {
+				fmt.Println("no tasks to archive")
+				return
+			}
+			if err := ef.Write(commandDescription()); err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			fmt.Printf("archived %d tasks\n", len(ts))
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+var archiveFlagBefore string
+
+func init() {
+	archiveCmd.PersistentFlags().StringVarP(&archiveFlagBefore, "before", "b", "", "archive tasks done or deleted before this date, e.g. 2018-01-31")
+	rootCmd.AddCommand(archiveCmd)
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/ryanberckmans/est/core/worktimes"
+	"github.com/spf13/cobra"
+)
+
+var burndownCmd = &cobra.Command{
+	Use:   "burndown",
+	Short: "Show whether the backlog of estimated tasks is shrinking",
+	Long: `Show whether the backlog of estimated tasks is shrinking
+
+est burndown [--since <date>] [--tag <tag>] [--output <file.png|file.svg>]
+
+Show the remaining estimated work of your backlog over time, and the estimated
+work added to and completed from it each week. The history is reconstructed
+from when tasks were estimated, done and deleted: a task is in the backlog
+from when it was last estimated until it was done or deleted. Deleted tasks
+never count as completed. Archived tasks aren't shown, see 'est archive'.
+
+The projected range of dates on which the remaining work will be done (10%,
+50% and 90% likely) is overlaid, predicted like 'est schedule', but including
+started tasks, scheduled for their estimate less the time already spent.
+
+By default, the burndown is drawn in the terminal and then summarized as a
+table by week. With --output, the chart is written to the passed PNG or SVG
+file instead. The burndown starts on --since, default the Monday eight weeks
+ago. --tag includes only tasks with the passed tag.
+
+Examples:
+  # Show the burndown of the last eight weeks.
+  est burndown
+
+  # Show the burndown of tasks tagged #auth since Jan 1st.
+  est burndown --since 2018-01-01 --tag auth
+
+  # Write the burndown chart to a PNG file.
+  est burndown -o burndown.png
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			now := time.Now()
+			wt := ec.WorkTimes()
+			today := worktimes.StartOfDay(now.In(wt.Location()))
+			since := today.AddDate(0, 0, -((int(today.Weekday())+6)%7)-7*7) // Monday eight weeks ago, counting this week
+			if burndownFlagSince != "" {
+				var err error
+				since, err = parseDate(burndownFlagSince, "since date", wt.Location())
+				if err != nil {
+					fmt.Println("fatal: " + err.Error())
+					os.Exit(1)
+					return
+				}
+			}
+			if since.After(now) {
+				fmt.Println("fatal: since date cannot be in the future")
+				os.Exit(1)
+				return
+			}
+			ts := ef.Tasks
+			if burndownFlagTag != "" {
+				ts = ts.HasTag(burndownFlagTag)
+			}
+			b := core.NewBurndown(wt, ts, since, now)
+			if remaining := ts.IsNotDeleted().IsEstimated().IsNotDone(); len(remaining) > 0 {
+				rs := core.PadFakeHistoricalEstimateAccuracyRatios(
+					ef.HistoricalEstimateAccuracyRatios().Ratios(), ef.FakeHistoricalEstimateAccuracyRatios)
+				b.Project(core.RemainingDeliverySchedule(wt, now, rs, remaining))
+			}
+			var summary bytes.Buffer
+			err := b.WriteTable(&summary)
+			if err == nil {
+				switch {
+				case burndownFlagOutput != "":
+					err = core.WriteBurndownChart(b, burndownFlagOutput)
+				case isTerminal(os.Stdout):
+					err = core.BurndownTerminalChart(b, b.RenderProjected())
+				}
+			}
+			if err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			os.Stdout.Write(summary.Bytes())
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+var burndownFlagSince string
+var burndownFlagTag string
+var burndownFlagOutput string
+
+func init() {
+	burndownCmd.PersistentFlags().StringVar(&burndownFlagSince, "since", "", "start the burndown on this date, e.g. 2018-01-31")
+	burndownCmd.PersistentFlags().StringVarP(&burndownFlagTag, "tag", "t", "", "include only tasks with this tag")
+	burndownCmd.PersistentFlags().StringVarP(&burndownFlagOutput, "output", "o", "", "write burndown chart to this PNG or SVG file, instead of drawing it in the terminal")
+	rootCmd.AddCommand(burndownCmd)
+}
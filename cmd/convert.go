@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Copy your estfile to another kind of storage",
+	Long: `Copy your estfile to another kind of storage
+
+est convert --to <storage> [<new estfile>]
+
+Storage is how your estfile is stored, and is set by "storage" in your
+estconfig. The kinds of storage are:
+
+  toml   (default) one TOML file, which is easy to read and edit by hand.
+
+  jsonl  an append-only log of changes, one JSON object per line. Saving
+         appends only the tasks which changed, so it's faster for large
+         estfiles, and the log keeps a history of recent changes. The log
+         is compacted to the current state of its tasks when it grows to
+         several records per task.
+
+'est convert' copies your estfile to a new file using the passed storage. The
+new estfile defaults to your estfile with its extension replaced, and mustn't
+already exist. Your estfile and estconfig aren't changed; to use the new
+estfile, set "estfile" and "storage" in your estconfig as shown after
+converting. Undo history isn't copied. Converting a jsonl estfile to jsonl
+compacts its log to the current state of its tasks.
+
+Examples:
+  # Copy your estfile to an append-only log alongside it.
+  est convert --to jsonl
+
+  # Copy your estfile to TOML, e.g. to edit it by hand.
+  est convert --to toml ~/estfile-copy.toml
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if convertFlagTo == "" || len(args) > 1 {
+			fmt.Println("usage: est convert --to <storage> [<new estfile>]")
+			os.Exit(1)
+			return
+		}
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			fileName := ef.Storage().FileName()
+			if len(args) > 0 {
+				fileName = args[0]
+			} else {
+				fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName)) + "." + convertFlagTo
+			}
+			s, err := core.NewStorage(convertFlagTo, fileName)
+			if err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			if err := ef.Convert(s); err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			fmt.Printf("copied %d tasks to %s\n", len(ef.Tasks), fileName)
+			fmt.Println("to use it, set in your estconfig:")
+			fmt.Printf("  estfile = \"%s\"\n", fileName)
+			fmt.Printf("  storage = \"%s\"\n", convertFlagTo)
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+var convertFlagTo string
+
+func init() {
+	convertCmd.PersistentFlags().StringVarP(&convertFlagTo, "to", "t", "", "storage to convert to, one of "+strings.Join(core.StorageKinds, ", "))
+	rootCmd.AddCommand(convertCmd)
+}
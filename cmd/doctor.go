@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check your estfile for problems and optionally fix them",
+	Long: `Check your estfile for problems and optionally fix them
+
+est doctor [--fix]
+
+est maintains invariants on tasks, such as "started tasks are never deleted",
+"actual time is never negative", and "task IDs are unique". Hand edits to an
+estfile can violate these invariants, causing est to misbehave or crash.
+
+'est doctor' checks every invariant and explains each problem found. Problems
+with a safe automatic fix are fixed with --fix. Other problems must be fixed by
+editing your estfile by hand. Fixes can be reverted with 'est undo'.
+
+'est doctor' exits with a non-zero status if any problems remain.
+
+Examples:
+  # Check estfile for problems.
+  est doctor
+
+  # Check estfile for problems and fix them.
+  est doctor --fix
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			now := time.Now()
+			ps := ef.Check(now)
+			if doctorFlagFix && len(ps) > 0 {
+				for _, p := range ef.Fix(ps) {
+					fmt.Println("fixed: " + core.RenderProblem(p))
+				}
+				if err := ef.Write(commandDescription()); err != nil {
+					fmt.Printf("fatal: %v\n", err)
+					os.Exit(1)
+					return
+				}
+				ps = ef.Check(now)
+			}
+			for _, p := range ps {
+				fmt.Println(core.RenderProblem(p))
+			}
+			if len(ps) > 0 {
+				fmt.Printf("%d problems found\n", len(ps))
+				os.Exit(1)
+				return
+			}
+			fmt.Println("no problems found")
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+var doctorFlagFix bool
+
+func init() {
+	doctorCmd.PersistentFlags().BoolVar(&doctorFlagFix, "fix", false, "apply safe automatic fixes")
+	rootCmd.AddCommand(doctorCmd)
+}
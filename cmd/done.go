@@ -27,8 +27,8 @@ performed mostly during working hours, there is no need to log time on task,
 est will do this automatically. For tasks performed mostly outside of working
 hours, see 'est log'.
 
-est's auto time tracking uses a customizable definition of working hours. For
-now, working hours are hardcoded in cmd/root.go.
+est's auto time tracking uses a customizable definition of working hours, set
+with workdays and workhours in your estconfig.
 
 When multiple tasks are started, est will share the passage of time equally
 among all started tasks. For example, if two tasks are started and time passes
@@ -36,7 +36,9 @@ from 9am to 10am during working hours, then each task will receive half the
 elapsed duration, which is thirty minutes.
 
 The time at which a task is marked done can be in the past with -a, using the
-same duration syntax as 'est estimate'.
+same duration syntax as 'est estimate', or with --at, using an absolute time
+such as "4:30pm", "yesterday 5pm" or "2018-01-31 17:00" in local time. The done
+time can't be before the task's most recent activity, e.g. when it was started.
 
 The (estimated hours, actual hours) for done tasks are used as data points to
 predict delivery schedule of future tasks in 'est schedule'.
@@ -50,6 +52,9 @@ Examples:
 
   # Mark the task with ID prefix "57" as done as of two and half hours ago.
   est d -a 2.5h 57
+
+  # Mark the task with ID prefix "57" as done as of 5pm yesterday.
+  est d --at "yesterday 5pm" 57
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) != 1 {
@@ -64,14 +69,15 @@ Examples:
 				os.Exit(1)
 				return
 			}
-			doneTime := applyFlagAgo(time.Now())
+			doneTime := applyTimeFlags(time.Now())
+			checkTimeFlags(ef.Tasks[i], doneTime)
 			doFlagLog(ef.Tasks[i], doneTime)
-			if err := ef.Tasks.Done(globalWorkTimes, i, doneTime); err != nil {
+			if err := ef.Tasks.Done(ec.WorkTimes(), i, doneTime); err != nil {
 				fmt.Printf("fatal: %v\n", err)
 				os.Exit(1)
 				return
 			}
-			if err := ef.Write(); err != nil {
+			if err := ef.Write(commandDescription()); err != nil {
 				fmt.Printf("fatal: %v\n", err)
 				os.Exit(1)
 				return
@@ -87,5 +93,6 @@ Examples:
 func init() {
 	doneCmd.PersistentFlags().StringVarP(&flagLog, "log", "l", "", "log time worked prior to marking this task as done (overrides auto time tracking)")
 	doneCmd.PersistentFlags().StringVarP(&flagAgo, "ago", "a", "", "done duration ago from now")
+	doneCmd.PersistentFlags().StringVar(&flagAt, "at", "", "done at this time, e.g. 4:30pm, \"yesterday 5pm\" or \"2018-01-31 17:00\"")
 	rootCmd.AddCommand(doneCmd)
 }
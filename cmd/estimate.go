@@ -26,6 +26,11 @@ Split large tasks such that estimates are below 16 hours. See 'est schedule'.
 
 The estimate can be provided as second argument or as -e.
 
+When an estimate is given, est suggests the likely actual time of the task,
+calibrated by how long your done tasks with estimates of similar size actually
+took: the median and 80th percentile. The suggestion needs at least 5 done
+tasks and doesn't change the estimate. Suppress it with --no-suggest.
+
 Examples:
   # Estimate the task with ID prefix "3c" at 7 hours.
   est e 3c 7h
@@ -64,12 +69,13 @@ Examples:
 				os.Exit(1)
 				return
 			}
-			if err := ef.Write(); err != nil {
+			if err := ef.Write(commandDescription()); err != nil {
 				fmt.Printf("fatal: %v\n", err)
 				os.Exit(1)
 				return
 			}
 			fmt.Println(core.RenderTaskOneLineSummary(ef.Tasks[i], true))
+			doFlagSuggest(ef, ef.Tasks[i].Name(), estimate)
 		}, func() {
 			// failed to load estconfig or estfile. Err printed elsewhere.
 			os.Exit(1)
@@ -79,5 +85,6 @@ Examples:
 
 func init() {
 	estimateCmd.PersistentFlags().StringVarP(&flagEstimate, "estimate", "e", "", "estimate task")
+	estimateCmd.PersistentFlags().BoolVar(&flagNoSuggest, "no-suggest", false, "don't suggest a calibrated estimate from history")
 	rootCmd.AddCommand(estimateCmd)
 }
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export tasks as JSON or CSV",
+	Long: `Export tasks as JSON or CSV
+
+est export [--format json|csv] [--since <date>] [--tag <tag>]
+
+Write all tasks to stdout in a machine-readable format, e.g. for spreadsheets
+or analysis in a notebook. Every task field is exported, along with each task's
+status, tags, and accuracy ratio (estimated hours / actual hours, for done
+tasks). Deleted tasks are included and marked "is_deleted".
+
+--since exports only tasks with any activity on or after the passed date.
+--tag exports only tasks with the passed tag. Tags are words in a task name
+prefixed with "#", e.g. "fix login bug #auth" has tag "auth".
+
+JSON schema (stable; fields may be added, see "schema_version"):
+
+` + core.ExportSchema + `
+CSV has one row per task with a header row of the same field names, excluding
+sessions and events. Tags are space-separated. Empty cells are null.
+
+Examples:
+  # Export all tasks as JSON.
+  est export > tasks.json
+
+  # Export tasks tagged #auth with activity since January 1st as CSV.
+  est export --format csv --since 2018-01-01 --tag auth > auth.csv
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if exportFlagFormat != "json" && exportFlagFormat != "csv" {
+			fmt.Println("fatal: format must be json or csv")
+			os.Exit(1)
+			return
+		}
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			var since time.Time
+			if exportFlagSince != "" {
+				var err error
+				since, err = parseDate(exportFlagSince, "since date", ec.WorkTimes().Location())
+				if err != nil {
+					fmt.Println("fatal: " + err.Error())
+					os.Exit(1)
+					return
+				}
+			}
+			ts := ef.Tasks.ActiveSince(since)
+			if exportFlagTag != "" {
+				ts = ts.HasTag(exportFlagTag)
+			}
+			e := core.NewExport(ts, time.Now())
+			var err error
+			if exportFlagFormat == "csv" {
+				err = e.WriteCSV(os.Stdout)
+			} else {
+				err = e.WriteJSON(os.Stdout)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+var exportFlagFormat string
+var exportFlagSince string
+var exportFlagTag string
+
+func init() {
+	exportCmd.PersistentFlags().StringVarP(&exportFlagFormat, "format", "f", "json", "export format, json or csv")
+	exportCmd.PersistentFlags().StringVar(&exportFlagSince, "since", "", "export only tasks with activity on or after this date, e.g. 2018-01-31")
+	exportCmd.PersistentFlags().StringVarP(&exportFlagTag, "tag", "t", "", "export only tasks with this tag")
+	rootCmd.AddCommand(exportCmd)
+}
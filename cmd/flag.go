@@ -6,6 +6,7 @@ import (
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ryanberckmans/est/core"
@@ -15,7 +16,9 @@ import (
 var flagLog string      // duration of logged time e.g. "30m"
 var flagEstimate string // duration estimate e.g. "2.5h"
 var flagAgo string      // duration ago e.g. "0.5d"
+var flagAt string       // absolute time e.g. "9:40am" or "yesterday 4pm"
 var flagMultiple bool   // user wants multiple tasks started vs. auto pausing any task in progress.
+var flagNoSuggest bool  // user doesn't want an estimate suggested from history
 
 // doFlagMultiple assumes that one task is about to be started and enforces
 // the semantics of pausing a task in progress or determining if a task
@@ -40,10 +43,19 @@ func doFlagMultiple(ef *core.EstFile, wt worktimes.WorkTimes, now time.Time) err
 	return nil
 }
 
+// startTask starts the ith task of passed EstFile at passed time, first
+// pausing any started task per --multiple, see doFlagMultiple().
+func startTask(ef *core.EstFile, wt worktimes.WorkTimes, i int, at time.Time) error {
+	if err := doFlagMultiple(ef, wt, at); err != nil {
+		return err
+	}
+	return ef.Tasks.Start(wt, i, at)
+}
+
 func doFlagLog(t *core.Task, now time.Time) {
-	if flagLog != "" && flagAgo != "" {
+	if flagLog != "" && (flagAgo != "" || flagAt != "") {
 		// --log and --ago may not co-occur because this creates weird auto time tracking issues which, while logically consistent, are probably really confusing to users.
-		fmt.Print("fatal: --log may not be used with --ago\n")
+		fmt.Print("fatal: --log may not be used with --ago or --at\n")
 		os.Exit(1)
 		return
 	}
@@ -66,21 +78,170 @@ func doFlagLog(t *core.Task, now time.Time) {
 	}
 }
 
-func applyFlagAgo(t time.Time) time.Time {
-	if flagAgo == "" {
+// doFlagSuggest prints a suggestion from history in passed EstFile for a
+// task with passed name and estimate, unless --no-suggest. For an estimated
+// task, that's a calibrated likely actual time, drawn from historical
+// estimates of similar size. For an unestimated task, that's an estimate
+// drawn from done tasks with similar names, see 'est suggest'. The task
+// itself isn't changed.
+func doFlagSuggest(ef *core.EstFile, name string, estimate time.Duration) {
+	if flagNoSuggest {
+		return
+	}
+	if estimate == 0 {
+		if s, ok := core.SuggestEstimate(core.SimilarDoneTasks(ef.Tasks, name, 5)); ok {
+			fmt.Printf("hint: %s, see 'est suggest'\n", s.Render())
+		}
+		return
+	}
+	if c, ok := ef.HistoricalEstimateAccuracyRatios().Calibrate(estimate); ok {
+		fmt.Println(c.Render())
+	}
+}
+
+// applyTimeFlags returns the time passed with --at, or passed now minus the
+// duration passed with --ago, or passed now if neither was passed.
+func applyTimeFlags(now time.Time) time.Time {
+	if flagAgo != "" && flagAt != "" {
+		fmt.Print("fatal: --ago may not be used with --at\n")
+		os.Exit(1)
+		return time.Time{}
+	}
+	if flagAt != "" {
+		t, err := parseAt(flagAt, now)
+		if err != nil {
+			fmt.Printf("fatal: %v\n", err)
+			os.Exit(1)
+			return time.Time{}
+		}
+		if t.After(now) {
+			fmt.Printf("fatal: --at time %s is in the future\n", t.Format(atTimeLayout))
+			os.Exit(1)
+			return time.Time{}
+		}
 		return t
 	}
+	if flagAgo == "" {
+		return now
+	}
 	ago, err := parseDurationHours(flagAgo, "duration ago")
 	if err != nil {
 		fmt.Printf("fatal: %v\n", err)
 		os.Exit(1)
 		return time.Time{}
 	}
-	return t.Add(-ago)
+	return now.Add(-ago)
+}
+
+// checkTimeFlags exits if --ago or --at was passed and the resulting time is
+// before the passed task's most recent activity. See Task.CheckTime().
+func checkTimeFlags(t *core.Task, at time.Time) {
+	if flagAgo == "" && flagAt == "" {
+		return
+	}
+	if err := t.CheckTime(at); err != nil {
+		fmt.Printf("fatal: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// parseDate parses a calendar date "YYYY-MM-DD" in passed location,
+// returning the start of that day.
+func parseDate(s string, name string, loc *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02", s, loc)
+	if err != nil {
+		return time.Time{}, errors.New("invalid " + name + ". For example, \"2018-01-31\".")
+	}
+	return t, nil
+}
+
+// timeLayouts are the layouts accepted by parseTime, in the passed location
+// unless the layout includes a time zone.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02 3:04pm",
+}
+
+// parseTime parses a time, e.g. "2018-01-31 14:00" in passed location.
+func parseTime(s string, name string, loc *time.Location) (time.Time, error) {
+	for _, l := range timeLayouts {
+		if t, err := time.ParseInLocation(l, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("invalid " + name + ". For example, \"2018-01-31 14:00\".")
+}
+
+// atTimeLayout is the layout in which --at times are shown to users.
+const atTimeLayout = "2006-01-02 3:04pm"
+
+// atClockLayouts are the layouts of a time of day accepted by parseAt.
+var atClockLayouts = []string{
+	"15:04",
+	"3:04pm",
+	"3pm",
+}
+
+// parseAt parses an absolute time in local time, relative to passed now. The
+// time is any layout accepted by parseTime, or an optional day and an
+// optional time of day, e.g. "9:40am", "14:00", "yesterday 4pm", "today",
+// "2018-01-31 9am" or "2018-01-31". The day is "today", "yesterday", or a
+// date "YYYY-MM-DD", and defaults to today. The time of day defaults to the
+// start of the day.
+func parseAt(s string, now time.Time) (time.Time, error) {
+	invalid := errors.New("invalid time \"" + s + "\". For example, \"9:40am\", \"14:00\", \"yesterday 4pm\" or \"2018-01-31 14:00\".")
+	s = strings.TrimSpace(s)
+	if t, err := parseTime(s, "time", time.Local); err == nil {
+		return t, nil
+	}
+	s = strings.ToLower(s)
+	fs := strings.Fields(s)
+	if len(fs) < 1 || len(fs) > 2 {
+		return time.Time{}, invalid
+	}
+	now = now.Local()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	switch fs[0] {
+	case "today":
+		fs = fs[1:]
+	case "yesterday":
+		day = day.AddDate(0, 0, -1)
+		fs = fs[1:]
+	default:
+		if d, err := parseDate(fs[0], "date", time.Local); err == nil {
+			day = d
+			fs = fs[1:]
+		}
+	}
+	if len(fs) == 0 {
+		return day, nil
+	}
+	if len(fs) > 1 {
+		return time.Time{}, invalid
+	}
+	for _, l := range atClockLayouts {
+		if c, err := time.Parse(l, fs[0]); err == nil {
+			return time.Date(day.Year(), day.Month(), day.Day(), c.Hour(), c.Minute(), 0, 0, time.Local), nil
+		}
+	}
+	return time.Time{}, invalid
 }
 
 var durationRegexp = regexp.MustCompile(`^([1-9][0-9]*(\.[0-9]*)?|0\.[0-9]+)(m|h)$`)
 
+var zeroDurationRegexp = regexp.MustCompile(`^0(\.0*)?(m|h)?$`)
+
+// parseDurationHoursOrZero is parseDurationHours, but also accepts a zero
+// duration, e.g. "0" or "0m".
+func parseDurationHoursOrZero(e string, name string) (time.Duration, error) {
+	if zeroDurationRegexp.MatchString(e) {
+		return 0, nil
+	}
+	return parseDurationHours(e, name)
+}
+
 // TODO unit test
 func parseDurationHours(e string, name string) (time.Duration, error) {
 	if e == "" {
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAt(t *testing.T) {
+	// Wednesday 2018-01-31, 3pm
+	now := time.Date(2018, 1, 31, 15, 0, 0, 0, time.Local)
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2018, 1, day, hour, min, 0, 0, time.Local)
+	}
+	tcs := []struct {
+		input    string
+		expected time.Time
+	}{
+		{"9:40am", at(31, 9, 40)},
+		{"9:40AM", at(31, 9, 40)},
+		{"14:00", at(31, 14, 0)},
+		{"4pm", at(31, 16, 0)},
+		{"today", at(31, 0, 0)},
+		{"yesterday 4pm", at(30, 16, 0)},
+		{"Yesterday 9:05am", at(30, 9, 5)},
+		{"2018-01-12 14:00", at(12, 14, 0)},
+		{"2018-01-12 2:30pm", at(12, 14, 30)},
+		{"2018-01-12 9am", at(12, 9, 0)},
+		{"2018-01-12", at(12, 0, 0)},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := parseAt(tc.input, now)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+	for _, s := range []string{"", "soon", "25:00", "yesterday 4pm today", "2018-13-01", "4pm yesterday"} {
+		t.Run("invalid "+s, func(t *testing.T) {
+			_, err := parseAt(s, now)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseDurationHoursOrZero(t *testing.T) {
+	tcs := []struct {
+		input    string
+		expected time.Duration
+	}{
+		{"0", 0},
+		{"0m", 0},
+		{"0h", 0},
+		{"0.0m", 0},
+		{"1h", time.Hour},
+		{"0.5h", 30 * time.Minute},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := parseDurationHoursOrZero(tc.input, "break length")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+	for _, s := range []string{"00m", "-5m", "5", "0.1"} {
+		t.Run("invalid "+s, func(t *testing.T) {
+			_, err := parseDurationHoursOrZero(s, "break length")
+			assert.Error(t, err)
+		})
+	}
+}
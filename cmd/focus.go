@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var focusCmd = &cobra.Command{
+	Use:   "focus",
+	Short: "Work on a task in timeboxed focus blocks with breaks",
+	Long: `Work on a task in timeboxed focus blocks with breaks
+
+est focus <task ID prefix> [--length 25m] [--break 5m] [--blocks <n>]
+
+Start a task and show a full-terminal countdown of focus blocks separated by
+breaks, as in the pomodoro technique. The task is started for each focus block
+and paused during each break, so auto time tracking only counts focus blocks.
+As with 'est start', any other started task is paused when the task is started.
+
+Each completed focus block is recorded in the task's history. When the focus
+session ends, after --blocks focus blocks or when you press Q to quit, the task
+is left paused and est shows the time focused compared to the task's estimate.
+A focus block which was quit early isn't recorded as completed, but its time is
+tracked as usual. With --break 0, focus blocks follow each other without
+breaks. 'est undo' undoes a whole focus session.
+
+Examples:
+  # Focus on the task with ID prefix "3c" in 25 minute blocks with 5 minute
+  # breaks, until Q is pressed.
+  est focus 3c
+
+  # Four 50 minute blocks with 10 minute breaks.
+  est focus 3c --length 50m --break 10m --blocks 4
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			fmt.Println("usage: est focus <task ID prefix> [--length 25m] [--break 5m] [--blocks <n>]")
+			os.Exit(1)
+			return
+		}
+		length, err := parseDurationHours(focusFlagLength, "focus block length")
+		if err != nil {
+			fmt.Println("fatal: " + err.Error())
+			os.Exit(1)
+			return
+		}
+		brk, err := parseDurationHoursOrZero(focusFlagBreak, "break length")
+		if err != nil {
+			fmt.Println("fatal: " + err.Error())
+			os.Exit(1)
+			return
+		}
+		now := time.Now()
+		f, err := core.NewFocus(length, brk, focusFlagBlocks, now)
+		if err != nil {
+			fmt.Printf("fatal: %v\n", err)
+			os.Exit(1)
+			return
+		}
+
+		// The estfile is reloaded for each change of phase, so that changes
+		// made by other est commands during a long focus session aren't
+		// overwritten. Only starting the focus session is recorded in undo
+		// history, so that 'est undo' undoes the whole focus session.
+		var id, name string
+		err = withFocusTask(args[0], true, func(ec *core.EstConfig, ef *core.EstFile, i int) error {
+			id, name = ef.Tasks[i].ID().String(), ef.Tasks[i].Name()
+			if ef.Tasks[i].IsStarted() {
+				return nil
+			}
+			return startTask(ef, ec.WorkTimes(), i, now)
+		})
+		if err != nil {
+			fmt.Printf("fatal: %v\n", err)
+			os.Exit(1)
+			return
+		}
+
+		err = core.FocusTimer(f, name, func(c core.FocusChange) error {
+			return withFocusTask(id, false, func(ec *core.EstConfig, ef *core.EstFile, i int) error {
+				t := ef.Tasks[i]
+				if c.From == core.FocusPhaseFocus {
+					t.AddFocusBlock(c.At.Add(-f.Length), c.At)
+				}
+				if c.To != core.FocusPhaseFocus && t.IsStarted() {
+					return ef.Tasks.Pause(ec.WorkTimes(), i, c.At)
+				}
+				if c.To == core.FocusPhaseFocus && !t.IsStarted() {
+					return startTask(ef, ec.WorkTimes(), i, c.At)
+				}
+				return nil
+			})
+		})
+		if err != nil {
+			fmt.Printf("fatal: %v\n", err)
+			os.Exit(1)
+			return
+		}
+
+		// Quit, or all focus blocks completed. Leave the task paused.
+		err = withFocusTask(id, false, func(ec *core.EstConfig, ef *core.EstFile, i int) error {
+			if ef.Tasks[i].IsStarted() {
+				if err := ef.Tasks.Pause(ec.WorkTimes(), i, time.Now()); err != nil {
+					return err
+				}
+			}
+			os.Stdout.WriteString(core.RenderFocusSummary(f, ef.Tasks[i]))
+			fmt.Println(core.RenderTaskOneLineSummary(ef.Tasks[i], true))
+			return nil
+		})
+		if err != nil {
+			fmt.Printf("fatal: %v\n", err)
+			os.Exit(1)
+			return
+		}
+	},
+}
+
+// withFocusTask passes the task with passed ID prefix to passed function,
+// and writes the estfile if the function succeeds. If first, the write is
+// recorded in undo history. Otherwise, the estfile is reloaded first, and the
+// write is undone along with the first write, see EstFile.WriteWithoutUndo().
+func withFocusTask(prefix string, first bool, fn func(ec *core.EstConfig, ef *core.EstFile, i int) error) error {
+	var err error
+	core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+		if !first {
+			if err = ef.Reload(); err != nil {
+				return
+			}
+		}
+		i := ef.Tasks.FindByIDPrefix(prefix)
+		if i < 0 {
+			err = fmt.Errorf("no task with ID prefix '%s'", prefix)
+			return
+		}
+		if err = fn(ec, ef, i); err != nil {
+			return
+		}
+		if first {
+			err = ef.Write(commandDescription())
+		} else {
+			err = ef.WriteWithoutUndo()
+		}
+	}, func() {
+		// failed to load estconfig or estfile. Err printed elsewhere.
+		err = errors.New("couldn't load estconfig or estfile")
+	})
+	return err
+}
+
+var focusFlagLength string
+var focusFlagBreak string
+var focusFlagBlocks int
+
+func init() {
+	focusCmd.PersistentFlags().StringVar(&focusFlagLength, "length", "25m", "length of each focus block")
+	focusCmd.PersistentFlags().StringVar(&focusFlagBreak, "break", "5m", "length of each break")
+	focusCmd.PersistentFlags().IntVar(&focusFlagBlocks, "blocks", 0, "number of focus blocks, until Q is pressed if zero")
+	rootCmd.AddCommand(focusCmd)
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"runtime"
 	"time"
 
 	"github.com/ryanberckmans/est/core"
@@ -15,25 +16,54 @@ var howamidoingCmd = &cobra.Command{
 	Short:   "Show accuracy of historical estimates",
 	Long: `Show accuracy of historical estimates
 
-est howamidoing
+est howamidoing [--terminal|--output <file.png|file.svg>]
 
 "How am I doing" provides a feedback loop for you to become a better estimator
-by showing a visualization of the accuracy of your historical estimates.
+by showing a visualization of the accuracy of your historical estimates, and a
+text summary of how many estimates were within 20%, 40% or 2x of actual time.
 
-The visualization is a dynamically generated PNG image in a temporary file,
-automatically opened in the operating system's default viewer.
+By default, the visualization is a dynamically generated PNG image in a
+temporary file, automatically opened in the operating system's default viewer.
+With --terminal, the visualization is drawn in the terminal instead, which is
+the default over SSH or where there's no display, e.g. in a headless container.
+With --output, the visualization is written to the passed PNG or SVG file.
 
 Shows last 90 days of history.
+
+Examples:
+  # Show accuracy in the default viewer, or in the terminal over SSH.
+  est howamidoing
+
+  # Show accuracy in the terminal.
+  est h -t
+
+  # Write accuracy chart to an SVG file.
+  est h -o accuracy.svg
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
 			now := time.Now()
 			ars := ef.HistoricalEstimateAccuracyRatios().After(now.Add(-time.Hour * 24 * 90)) // show only 90 days of history to ensure chart is readable and history eventually drops off (hopefully estimator improves)
-			if err := core.AccuracyRatioChart(ars, now); err != nil {
+			summary := core.RenderAccuracyRatioSummary(ars)
+			var err error
+			switch {
+			case howamidoingFlagOutput != "":
+				err = core.WriteAccuracyRatioChart(ars, now, howamidoingFlagOutput)
+			case howamidoingFlagTerminal || isHeadless():
+				if !isTerminal(os.Stdout) {
+					// e.g. piped, so the text summary is all that's useful
+					break
+				}
+				err = core.AccuracyRatioTerminalChart(ars, now, summary)
+			default:
+				err = core.AccuracyRatioChart(ars, now)
+			}
+			if err != nil {
 				fmt.Println("fatal: " + err.Error())
 				os.Exit(1)
 				return
 			}
+			os.Stdout.WriteString(summary)
 		}, func() {
 			// failed to load estconfig or estfile. Err printed elsewhere.
 			os.Exit(1)
@@ -41,6 +71,20 @@ Shows last 90 days of history.
 	},
 }
 
+// isHeadless returns true iff est probably can't open a viewer for charts,
+// e.g. over SSH or in a container without a display.
+func isHeadless() bool {
+	if os.Getenv("SSH_CONNECTION") != "" {
+		return true
+	}
+	return runtime.GOOS == "linux" && os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == ""
+}
+
+var howamidoingFlagTerminal bool
+var howamidoingFlagOutput string
+
 func init() {
+	howamidoingCmd.PersistentFlags().BoolVarP(&howamidoingFlagTerminal, "terminal", "t", false, "show accuracy chart in the terminal")
+	howamidoingCmd.PersistentFlags().StringVarP(&howamidoingFlagOutput, "output", "o", "", "write accuracy chart to this PNG or SVG file, instead of opening a viewer")
 	rootCmd.AddCommand(howamidoingCmd)
 }
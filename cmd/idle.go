@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var idleCmd = &cobra.Command{
+	Use:   "idle",
+	Short: "Show or pause started tasks which were idle while you were away",
+	Long: `Show or pause started tasks which were idle while you were away
+
+est idle [--pause|--ignore]
+
+est-prompt runs on every shell prompt, so it can record shell activity as a
+heartbeat. If there's no shell activity for more than idleminutes working
+minutes (default 60) while tasks are started, est-prompt records an idle gap.
+Auto time tracking keeps adding working hours to started tasks while you're
+away, so the next est command handles idle gaps per the idle policy in your
+estconfig:
+
+  off    est-prompt doesn't record shell activity (default)
+  ask    est asks whether to pause started tasks as of the start of the gap;
+         if est can't ask, e.g. in a script, it warns instead
+  pause  est pauses started tasks as of the start of the gap
+
+Tasks are paused retroactively, removing time auto tracked on them since the
+start of the gap. Tasks with activity during the gap, e.g. time logged from
+another machine, aren't paused. Each retroactive pause is recorded on the task
+and shown by 'est session ls <task ID prefix>'.
+
+With no flags, show idle gaps and the started tasks they would pause. --pause
+pauses those tasks, and --ignore forgets the gaps without pausing any tasks.
+
+Examples:
+  # In your estconfig, ask about idle gaps of at least 45 working minutes.
+  idle = "ask"
+  idleminutes = 45
+
+  # Show idle gaps.
+  est idle
+
+  # Pause started tasks as of the start of the first idle gap.
+  est idle --pause
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 || (idleFlagPause && idleFlagIgnore) {
+			fmt.Println("usage: est idle [--pause|--ignore]")
+			os.Exit(1)
+			return
+		}
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			gs, err := ef.IdleGaps()
+			if err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			if len(gs) == 0 {
+				fmt.Println("no idle gaps")
+				return
+			}
+			switch {
+			case idleFlagPause:
+				if err := pauseIdle(ec, ef, gs, false); err != nil {
+					fmt.Printf("fatal: %v\n", err)
+					os.Exit(1)
+					return
+				}
+			case idleFlagIgnore:
+				if err := ef.ClearIdleGaps(); err != nil {
+					fmt.Printf("fatal: %v\n", err)
+					os.Exit(1)
+					return
+				}
+				fmt.Printf("ignored %d idle gaps\n", len(gs))
+			default:
+				os.Stdout.WriteString(renderIdleGaps(ef, gs))
+				fmt.Println("\nTo pause these tasks, run 'est idle --pause'. To ignore these gaps, run 'est idle --ignore'.")
+			}
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+// checkIdle handles idle gaps recorded by est-prompt per the idle policy in
+// estconfig, see 'est help idle'. It's run before each command, except
+// commands which don't use the estfile, and undo and redo, which would
+// otherwise undo or redo the idle pause instead of the user's changes. The
+// estfile it loads is shared with the command, see core.WithEstConfigAndFile().
+func checkIdle(cmd *cobra.Command) {
+	if isCommand(cmd, "idle", "prompt", "bash", "help", "undo", "redo") {
+		return
+	}
+	core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+		if ec.Idle == core.IdleOff {
+			return
+		}
+		gs, err := ef.IdleGaps()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: couldn't read idle gaps: %v\n", err)
+			return
+		}
+		if len(gs) == 0 {
+			return
+		}
+		if ec.Idle == core.IdleAsk {
+			os.Stderr.WriteString(renderIdleGaps(ef, gs))
+			if !isTerminal(os.Stdin) {
+				fmt.Fprintln(os.Stderr, "warning: started tasks may have been idle, see 'est idle'")
+				return
+			}
+			fmt.Fprint(os.Stderr, "Pause these tasks as of the start of the gap? [y/N] ")
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+				if err := ef.ClearIdleGaps(); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: couldn't ignore idle gaps: %v\n", err)
+				}
+				return
+			}
+		}
+		if err := pauseIdle(ec, ef, gs, true); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: couldn't pause idle tasks: %v\n", err)
+		}
+	}, func() {
+		// failed to load estconfig or estfile. Err printed elsewhere.
+	})
+}
+
+// pauseIdle pauses the started tasks which were idle during passed gaps,
+// writes the estfile, and forgets the gaps. If auto, the pause is written as
+// an automatic change, see EstFile.WriteAuto().
+func pauseIdle(ec *core.EstConfig, ef *core.EstFile, gs []core.IdleGap, auto bool) error {
+	paused, err := ef.Tasks.PauseIdle(ec.WorkTimes(), gs, time.Now())
+	if err != nil {
+		return err
+	}
+	write := ef.Write
+	if auto {
+		write = ef.WriteAuto
+	}
+	if err := write(fmt.Sprintf("pause idle tasks (%s)", gs[0].Render())); err != nil {
+		return err
+	}
+	if err := ef.ClearIdleGaps(); err != nil {
+		return err
+	}
+	if len(paused) > 0 {
+		fmt.Fprintf(os.Stderr, "paused %d idle tasks:\n", len(paused))
+	}
+	for i, t := range paused {
+		fmt.Fprintln(os.Stderr, core.RenderTaskOneLineSummary(t, i == 0))
+	}
+	return nil
+}
+
+// renderIdleGaps returns a user-suitable description of passed idle gaps
+// and the started tasks they would pause.
+func renderIdleGaps(ef *core.EstFile, gs []core.IdleGap) string {
+	var b strings.Builder
+	for _, g := range gs {
+		fmt.Fprintf(&b, "%s\n", g.Render())
+	}
+	for _, t := range ef.Tasks.IdleDuring(gs) {
+		fmt.Fprintf(&b, "  started: %s %s\n", t.ID().String()[0:5], t.Name())
+	}
+	return b.String()
+}
+
+// isTerminal returns true iff passed file is probably a terminal, e.g. so
+// that est only asks questions of humans. The null device is a character
+// device, but isn't a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(fi, null)
+}
+
+var idleFlagPause bool
+var idleFlagIgnore bool
+
+func init() {
+	idleCmd.PersistentFlags().BoolVar(&idleFlagPause, "pause", false, "pause idle tasks as of the start of the first idle gap")
+	idleCmd.PersistentFlags().BoolVar(&idleFlagIgnore, "ignore", false, "forget idle gaps without pausing any tasks")
+	rootCmd.AddCommand(idleCmd)
+}
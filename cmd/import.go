@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import tasks from a file",
+	Long: `Import tasks from a file
+
+est import [--dry-run] [--format json|csv] <file>
+
+Import tasks, such as your history of estimates and actuals from another
+tracker. Imported done tasks which have an estimate and actual time count as
+evidence for 'est schedule', so that you needn't start from fake evidence.
+Done tasks without an estimate are imported, but aren't evidence; the import
+reports how many there are.
+
+Supported formats:
+
+  JSON  est's own export (see 'est help export'), or Taskwarrior's export from
+        'task export'. Taskwarrior has no estimates or actuals, so est reads the
+        optional user defined attributes "estimate" and "actual", in hours or
+        as durations like "PT2H30M". If "actual" is missing, the working hours
+        between a task's start and end are used. Taskwarrior tags become
+        #tags in the task name.
+
+  CSV   ` + strings.Replace(core.ImportCSVLayout, "\n", "\n        ", -1) + `
+
+The format is inferred from the file extension unless given with --format.
+
+Imported tasks which are started are imported as paused. Tasks which duplicate
+an existing task are skipped. A task is a duplicate if it has the same ID as
+an existing task, or the same name and either both are not done or both were
+done at the same time.
+
+Use --dry-run to show what would be imported without changing your estfile.
+An import can be reverted with 'est undo'.
+
+Examples:
+  # Preview an import of a Taskwarrior export.
+  task export > tw.json && est import --dry-run tw.json
+
+  # Import a spreadsheet of historical tasks.
+  est import history.csv
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			fmt.Println("usage: est import [--dry-run] [--format json|csv] <file>")
+			os.Exit(1)
+			return
+		}
+		format := importFlagFormat
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(args[0])), ".")
+		}
+		if format != "json" && format != "csv" {
+			fmt.Println("fatal: couldn't infer format from file extension, use --format json or --format csv")
+			os.Exit(1)
+			return
+		}
+		f, err := os.Open(args[0])
+		if err != nil {
+			fmt.Printf("fatal: %v\n", err)
+			os.Exit(1)
+			return
+		}
+		defer func() { _ = f.Close() }()
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			var ts []*core.Task
+			var err error
+			if format == "csv" {
+				ts, err = core.ImportTasksFromCSV(f)
+			} else {
+				ts, err = core.ImportTasksFromJSON(f, ec.WorkTimes())
+			}
+			if err != nil {
+				fmt.Printf("fatal: couldn't import %s: %v\n", args[0], err)
+				os.Exit(1)
+				return
+			}
+			added, duplicates := ef.Import(ts)
+			for i := range added {
+				fmt.Println(core.RenderTaskOneLineSummary(added[i], i == 0))
+			}
+			verb := "imported"
+			if importFlagDryRun {
+				verb = "would import"
+			}
+			fmt.Printf("%s %d tasks, skipped %d duplicates\n", verb, len(added), len(duplicates))
+			unestimated := 0
+			for _, t := range added {
+				if t.IsDone() && !t.IsEstimated() {
+					unestimated++
+				}
+			}
+			if unestimated > 0 {
+				fmt.Printf("%d done tasks have no estimate, so they aren't evidence for 'est schedule'\n", unestimated)
+			}
+			if importFlagDryRun || len(added) == 0 {
+				return
+			}
+			if err := ef.Write(commandDescription()); err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+var importFlagDryRun bool
+var importFlagFormat string
+
+func init() {
+	importCmd.PersistentFlags().BoolVarP(&importFlagDryRun, "dry-run", "n", false, "show what would be imported without changing estfile")
+	importCmd.PersistentFlags().StringVarP(&importFlagFormat, "format", "f", "", "import format, json or csv (default inferred from file extension)")
+	rootCmd.AddCommand(importCmd)
+}
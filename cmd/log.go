@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -15,7 +16,9 @@ var logCmd = &cobra.Command{
 	Short:   "Log time worked on a task (in lieu of auto time tracking)",
 	Long: `Log time worked on a task (in lieu of auto time tracking)
 
-est log <task ID prefix> <duration>
+est log <task ID prefix> <duration> [--on <date>]
+est log <task ID prefix> --from <time> --to <time> [<duration>]
+est log <task ID prefix> --subtract <duration>
 
 Most users should not use 'est log' and instead rely on auto time tracking. See
 'est help done' for an explanation of auto time tracking and how it interacts
@@ -39,6 +42,23 @@ avoid running 'est log' directly.
 The logged duration uses the same syntax as 'est estimate', and can be provided
 as second argument or as -l.
 
+By default, time is logged as worked just now. To log time worked in the past,
+e.g. filling in Saturday's hours on Monday, use --on to log a duration on a
+date, or --from and --to to log the time between two times. With --from and
+--to, the duration defaults to the time between them. Time logged in the past
+doesn't affect auto time tracking, and can't overlap time auto tracked on other
+tasks. With --on, time on a workday must fit in that day's working hours, e.g.
+3h can't be logged on a workday of 8 working hours with 6h auto tracked on
+other tasks; use --from and --to to log time outside working hours. 2-3pm can't
+be logged while another task was started. Dates and times are "YYYY-MM-DD
+HH:MM" in the timezone of your working hours.
+
+To correct a task's actual time, e.g. because the task was left started during
+a meeting, use --subtract to remove time from the task's most recent sessions.
+Actual time can't become negative. Each correction is recorded on the task and
+shown by 'est session ls <task ID prefix>'. See also 'est set-actual', and 'est
+session' to correct individual sessions.
+
 Examples:
   # Log 7 hours worked on the task with ID prefix "3c".
   est l 3c 7h
@@ -48,26 +68,82 @@ Examples:
 
   # Log 45 minutes worked on the task with ID prefix "94".
   est -l 0.75h 94
+
+  # Log 3 hours worked on Saturday January 27.
+  est l 3c 3h --on 2018-01-27
+
+  # Log 2-4:30pm on January 27.
+  est l 3c --from "2018-01-27 14:00" --to "2018-01-27 16:30"
+
+  # The task was left started during a 2h meeting; subtract 2 hours.
+  est l 3c 2h --subtract
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		if flagLog == "" && len(args) > 1 {
 			// est log can take either <log> or --log <duration>
 			flagLog = args[1]
 		}
-		if flagLog == "" {
-			fmt.Println("usage: est log <task ID prefix> [-l] <duration>")
+		isInterval := logFlagFrom != "" || logFlagTo != ""
+		if len(args) < 1 || (flagLog == "" && !isInterval) {
+			fmt.Println("usage: est log <task ID prefix> [-l] <duration> [--on <date>]")
+			fmt.Println("       est log <task ID prefix> --from <time> --to <time> [<duration>]")
+			os.Exit(1)
+			return
+		}
+		if logFlagSubtract && (logFlagOn != "" || logFlagFrom != "" || logFlagTo != "" || flagAgo != "") {
+			fmt.Println("fatal: --subtract may not be used with --on, --from, --to or --ago")
 			os.Exit(1)
 			return
 		}
 		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			now := time.Now()
+			from, to, err := logRange(ec.WorkTimes().Location())
+			if err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
 			i := ef.Tasks.FindByIDPrefix(args[0])
 			if i < 0 {
 				fmt.Printf("fatal: no task with ID prefix '%s'\n", args[0])
 				os.Exit(1)
 				return
 			}
-			doFlagLog(ef.Tasks[i], time.Now())
-			if err := ef.Write(); err != nil {
+			if logFlagSubtract {
+				d, err := parseDurationHours(flagLog, "log duration")
+				if err != nil {
+					fmt.Printf("fatal: %v\n", err)
+					os.Exit(1)
+					return
+				}
+				if err := ef.Tasks.SubtractActual(ec.WorkTimes(), i, d, now); err != nil {
+					fmt.Printf("fatal: %v\n", err)
+					os.Exit(1)
+					return
+				}
+			} else if from.IsZero() {
+				doFlagLog(ef.Tasks[i], now)
+			} else {
+				d := to.Sub(from)
+				if flagLog != "" {
+					if d, err = parseDurationHours(flagLog, "log duration"); err != nil {
+						fmt.Printf("fatal: %v\n", err)
+						os.Exit(1)
+						return
+					}
+				}
+				if logFlagOn != "" {
+					err = ef.Tasks.LogActualOnDay(ec.WorkTimes(), i, from, d, now)
+				} else {
+					err = ef.Tasks.LogActual(ec.WorkTimes(), i, from, to, d, now)
+				}
+				if err != nil {
+					fmt.Printf("fatal: %v\n", err)
+					os.Exit(1)
+					return
+				}
+			}
+			if err := ef.Write(commandDescription()); err != nil {
 				fmt.Printf("fatal: %v\n", err)
 				os.Exit(1)
 				return
@@ -80,7 +156,48 @@ Examples:
 	},
 }
 
+// logRange returns the interval in which to log time per --on, --from and
+// --to in passed working location, or zero times if time should be logged as
+// worked just now. With --on, the interval is the whole day, see
+// LogActualOnDay().
+func logRange(loc *time.Location) (time.Time, time.Time, error) {
+	if logFlagOn != "" {
+		if logFlagFrom != "" || logFlagTo != "" {
+			return time.Time{}, time.Time{}, errors.New("--on may not be used with --from or --to")
+		}
+		day, err := parseDate(logFlagOn, "date", loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		return day, day.AddDate(0, 0, 1), nil
+	}
+	if logFlagFrom == "" && logFlagTo == "" {
+		return time.Time{}, time.Time{}, nil
+	}
+	if logFlagFrom == "" || logFlagTo == "" {
+		return time.Time{}, time.Time{}, errors.New("--from and --to must be used together")
+	}
+	from, err := parseTime(logFlagFrom, "from time", loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to, err := parseTime(logFlagTo, "to time", loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return from, to, nil
+}
+
+var logFlagOn string
+var logFlagFrom string
+var logFlagTo string
+var logFlagSubtract bool
+
 func init() {
 	logCmd.PersistentFlags().StringVarP(&flagLog, "log", "l", "", "log time worked")
+	logCmd.PersistentFlags().StringVar(&logFlagOn, "on", "", "log time worked on this date, e.g. 2018-01-27")
+	logCmd.PersistentFlags().StringVar(&logFlagFrom, "from", "", "log time worked from this time, e.g. \"2018-01-27 14:00\"")
+	logCmd.PersistentFlags().BoolVar(&logFlagSubtract, "subtract", false, "subtract the duration from the task's actual time")
+	logCmd.PersistentFlags().StringVar(&logFlagTo, "to", "", "log time worked up to this time, e.g. \"2018-01-27 16:30\"")
 	rootCmd.AddCommand(logCmd)
 }
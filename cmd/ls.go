@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"strings"
 
@@ -20,10 +21,19 @@ var lsCmd = &cobra.Command{
 func doLS() {
 	core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
 		ts := ef.Tasks.SortByStatusDescending()
-		if !lsFlagDeleted {
+		if lsFlagArchived {
+			var err error
+			ts, err = ef.ArchivedTasks()
+			if err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			ts = ts.SortByStatusDescending()
+		} else if !lsFlagDeleted {
 			ts = ts.IsNotDeleted()
 		}
-		if !lsFlagDone {
+		if !lsFlagDone && !lsFlagArchived {
 			ts = ts.IsNotDone()
 		}
 		rs := make([]string, len(ts)+1) // +1 causes the last element to be empty string, which causes the Join to add an extra newline
@@ -37,11 +47,13 @@ func doLS() {
 	})
 }
 
-var lsFlagDone bool    // show done tasks
-var lsFlagDeleted bool // show deleted tasks
+var lsFlagDone bool     // show done tasks
+var lsFlagDeleted bool  // show deleted tasks
+var lsFlagArchived bool // show archived tasks instead of tasks in estfile
 
 func init() {
 	lsCmd.PersistentFlags().BoolVarP(&lsFlagDone, "done", "d", false, "show done tasks")
 	lsCmd.PersistentFlags().BoolVarP(&lsFlagDeleted, "deleted", "D", false, "show deleted tasks")
+	lsCmd.PersistentFlags().BoolVar(&lsFlagArchived, "archived", false, "show archived tasks, see 'est help archive'")
 	rootCmd.AddCommand(lsCmd)
 }
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge",
+	Short: "Merge another estfile into your estfile",
+	Long: `Merge another estfile into your estfile
+
+est merge [--dry-run] <other estfile>
+
+Merge tasks from another estfile, such as a conflicted copy created by a file
+sync tool when est was used offline on two machines. The other estfile isn't
+changed.
+
+Tasks are matched by ID. Tasks only in the other estfile are added. For tasks
+in both estfiles, each field is resolved by the time at which it last changed:
+the estimate with the later estimated time wins, the status with the later
+start, pause, or done time wins, and so on.
+
+Actual time is the union of both copies' sessions (see 'est help session'),
+matched by session ID, so time tracked before the copies diverged isn't
+double-counted. A session in both copies keeps the copy which ends later, e.g.
+because auto time tracking extended it. Auto tracked sessions on both copies
+which overlap in time would double-count time tracked on both machines while
+the task was started, so they're reported as a conflict.
+
+Tasks in the other estfile which you've already archived aren't added, see 'est
+help archive'.
+
+True conflicts, such as a task renamed differently on each machine, keep the
+value in your estfile and are reported. A merge can be reverted with 'est undo'.
+
+Examples:
+  # Preview merging a conflicted copy made by a sync tool.
+  est merge --dry-run "~/Dropbox/estfile (conflicted copy).toml"
+
+  # Merge the conflicted copy.
+  est merge "~/Dropbox/estfile (conflicted copy).toml"
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			fmt.Println("usage: est merge [--dry-run] <other estfile>")
+			os.Exit(1)
+			return
+		}
+		their, err := core.LoadEstFile(args[0])
+		if err != nil {
+			fmt.Printf("fatal: %v\n", err)
+			os.Exit(1)
+			return
+		}
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			archived, err := ef.ArchivedTasks()
+			if err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			r := ef.Merge(their, archived)
+			if len(r.Added) > 0 {
+				fmt.Println("Added:")
+				for i := range r.Added {
+					fmt.Println(core.RenderTaskOneLineSummary(r.Added[i], i == 0))
+				}
+			}
+			if len(r.Updated) > 0 {
+				fmt.Println("Updated:")
+				for i := range r.Updated {
+					fmt.Println(core.RenderTaskOneLineSummary(r.Updated[i], i == 0))
+				}
+			}
+			if len(r.Archived) > 0 {
+				fmt.Println("Already archived, not added:")
+				for i := range r.Archived {
+					fmt.Println(core.RenderTaskOneLineSummary(r.Archived[i], i == 0))
+				}
+			}
+			for _, c := range r.Conflicts {
+				fmt.Printf("conflict: task %s \"%s\": %s\n", c.Task.ID().String()[0:5], c.Task.Name(), c.Explanation)
+			}
+			fmt.Printf("added %d tasks, updated %d tasks, %d conflicts\n", len(r.Added), len(r.Updated), len(r.Conflicts))
+			if mergeFlagDryRun {
+				return
+			}
+			if err := ef.Write(commandDescription()); err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+var mergeFlagDryRun bool
+
+func init() {
+	mergeCmd.PersistentFlags().BoolVarP(&mergeFlagDryRun, "dry-run", "n", false, "show what would be merged without changing estfile")
+	rootCmd.AddCommand(mergeCmd)
+}
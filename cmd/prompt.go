@@ -19,6 +19,13 @@ Add est-prompt to your bash prompt by adding this snippet into your PS1 variable
 """
 $(est-prompt 2> /dev/null)
 """
+
+est-prompt honours $EST_CONFIG, $EST_FILE, and $EST_PROFILE, and also accepts
+--config, --estfile, and --profile, e.g. "$(est-prompt --profile work)". See
+'est help'.
+
+If idle is set in your estconfig, est-prompt also records shell activity, so
+that started tasks can be paused while you're away. See 'est help idle'.
 `
 
 // promptCmd represents the prompt command
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var redoCmd = &cobra.Command{
+	Use:   "redo",
+	Short: "Redo changes to your estfile which were undone",
+	Long: `Redo changes to your estfile which were undone
+
+est redo [<number of changes>]
+
+Reapply the most recent changes undone by 'est undo'. By default one change is
+redone; pass a number to redo that many changes.
+
+Any command which changes the estfile, such as 'est add', discards changes
+which were undone and not yet redone.
+
+Examples:
+  # Redo the most recently undone change.
+  est redo
+
+  # Redo the two most recently undone changes.
+  est redo 2
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runUndoOrRedo(args, "redo", "Redid", (*core.EstFile).Redo)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(redoCmd)
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/ryanberckmans/est/core/worktimes"
+	"github.com/spf13/cobra"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Show a timesheet of hours worked per task per day",
+	Long: `Show a timesheet of hours worked per task per day
+
+est report [--week|--day|--since <date> [--until <date>]] [--format table|csv|markdown] [--tag <tag>]
+
+Show a grid of tasks by days, with the hours worked on each task on each day,
+and totals per task and per day. Only tasks with time worked in the report's
+days are shown. Hours come from tasks' sessions (see 'est help session'),
+including time tracked on started tasks up to now. A session spanning multiple
+days, e.g. one logged with 'est log', is divided between its days in
+proportion to the working hours of each day.
+
+By default, the report is for this week, starting Monday. --day reports on
+today only. --since and --until report on a range of dates, inclusive; --until
+defaults to today.
+
+Formats:
+  table     aligned columns, for humans (default)
+  csv       one row per task, hours as decimals, e.g. for spreadsheets
+  markdown  a markdown table, e.g. for pasting into an issue or status update
+
+Examples:
+  # Hours per task per day this week.
+  est report
+
+  # Hours per task today.
+  est report --day
+
+  # January's timesheet as CSV.
+  est report --since 2018-01-01 --until 2018-01-31 --format csv > jan.csv
+
+  # Last week's hours on tasks tagged #auth, as markdown.
+  est report --since 2018-01-22 --until 2018-01-28 --tag auth -f markdown
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if reportFlagFormat != "table" && reportFlagFormat != "csv" && reportFlagFormat != "markdown" {
+			fmt.Println("fatal: format must be table, csv or markdown")
+			os.Exit(1)
+			return
+		}
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			now := time.Now()
+			since, until, err := reportRange(now, ec.WorkTimes().Location())
+			if err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			ts := ef.Tasks.IsNotDeleted()
+			if reportFlagTag != "" {
+				ts = ts.HasTag(reportFlagTag)
+			}
+			r := core.NewReport(ec.WorkTimes(), ts, since, until, now)
+			switch reportFlagFormat {
+			case "csv":
+				err = r.WriteCSV(os.Stdout)
+			case "markdown":
+				err = r.WriteMarkdown(os.Stdout)
+			default:
+				err = r.WriteTable(os.Stdout)
+			}
+			if err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+// reportRange returns the start of the report's first day and the start of
+// the day after the report's last day in passed working location, per report
+// flags.
+func reportRange(now time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	today := worktimes.StartOfDay(now.In(loc))
+	n := 0
+	for _, b := range []bool{reportFlagWeek, reportFlagDay, reportFlagSince != "" || reportFlagUntil != ""} {
+		if b {
+			n++
+		}
+	}
+	if n > 1 {
+		return time.Time{}, time.Time{}, fmt.Errorf("--week, --day and --since/--until may not be used together")
+	}
+	switch {
+	case reportFlagDay:
+		return today, today.AddDate(0, 0, 1), nil
+	case reportFlagSince != "" || reportFlagUntil != "":
+		if reportFlagSince == "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("--until requires --since")
+		}
+		since, err := parseDate(reportFlagSince, "since date", loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		until := today
+		if reportFlagUntil != "" {
+			if until, err = parseDate(reportFlagUntil, "until date", loc); err != nil {
+				return time.Time{}, time.Time{}, err
+			}
+		}
+		if until.Before(since) {
+			return time.Time{}, time.Time{}, fmt.Errorf("until date must not be before since date")
+		}
+		return since, until.AddDate(0, 0, 1), nil
+	}
+	// This week, starting Monday.
+	monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	return monday, monday.AddDate(0, 0, 7), nil
+}
+
+var reportFlagWeek bool
+var reportFlagDay bool
+var reportFlagSince string
+var reportFlagUntil string
+var reportFlagFormat string
+var reportFlagTag string
+
+func init() {
+	reportCmd.PersistentFlags().BoolVarP(&reportFlagWeek, "week", "w", false, "report on this week, starting Monday (default)")
+	reportCmd.PersistentFlags().BoolVarP(&reportFlagDay, "day", "d", false, "report on today")
+	reportCmd.PersistentFlags().StringVar(&reportFlagSince, "since", "", "report on days from this date, e.g. 2018-01-01")
+	reportCmd.PersistentFlags().StringVar(&reportFlagUntil, "until", "", "report on days up to and including this date, e.g. 2018-01-31 (default today)")
+	reportCmd.PersistentFlags().StringVarP(&reportFlagFormat, "format", "f", "table", "report format, table, csv or markdown")
+	reportCmd.PersistentFlags().StringVarP(&reportFlagTag, "tag", "t", "", "report only on tasks with this tag")
+	rootCmd.AddCommand(reportCmd)
+}
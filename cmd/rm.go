@@ -18,8 +18,8 @@ est rm <task ID prefix>
 Delete an existing task. To specify the task to delete, use a prefix of the task
 ID shown in 'est ls'.
 
-Tasks are soft deleted by setting a flag on the task. You can restore deleted
-tasks by editing your estfile directly and toggling this flag.
+Tasks are soft deleted by setting a flag on the task. You can restore a task
+deleted by mistake with 'est undo'.
 
 Deleted tasks are not used as prediction data in 'est schedule'.
 
@@ -48,7 +48,7 @@ Examples:
 				os.Exit(1)
 				return
 			}
-			if err := ef.Write(); err != nil {
+			if err := ef.Write(commandDescription()); err != nil {
 				fmt.Printf("fatal: %v\n", err)
 				os.Exit(1)
 				return
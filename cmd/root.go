@@ -3,47 +3,44 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"time"
+	"strings"
 
-	"github.com/ryanberckmans/est/core/worktimes"
+	"github.com/ryanberckmans/est/core"
 	"github.com/spf13/cobra"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "est",
 	Short: "est is a command-line tool for software estimation",
-	Long:  `est is a command-line tool for software estimation.`,
+	Long: `est is a command-line tool for software estimation.
+
+By default est uses the estfile and working hours set in your estconfig. These
+can be overridden for one command with --config, --estfile, or --profile, or
+for a shell session with the environment variables $EST_CONFIG, $EST_FILE, or
+$EST_PROFILE. Flags take precedence over environment variables. Profiles are
+defined in your estconfig, see the comments in that file.`,
 }
 
-var globalWorkTimes worktimes.WorkTimes
+var flagConfig string  // estconfig file name
+var flagEstfile string // estfile name
+var flagProfile string // estconfig profile name
 
 func init() {
-	// TODO construct WorkTimes from estconfig
-	var err error
-	// When customizing globalWorkTimes, tend to understate working hours, so that
-	// time worked outside of working hours is a bonus and, if not worked, not a
-	// penalty. For example, one might start work earlier, end later, or work on
-	// weekends, however the working hours below are only 9:30-noon + 12:30-5:30pm.
-	globalWorkTimes, err = worktimes.New(map[time.Weekday]bool{
-		time.Monday:    true,
-		time.Tuesday:   true,
-		time.Wednesday: true,
-		time.Thursday:  true,
-		time.Friday:    true,
-	}, []string{
-		// Work 9:30am-noon
-		"9:30am",
-		"12:00pm",
-		// 30 minutes for lunch, then work 12:30pm-5:30pm
-		"12:30pm",
-		"5:30pm",
-		// Time on tasks outside of these times will not count towards automatic time tracking. This doesn't mean no work occurs outside of these times, it just means the estimator isn't penalized (by additional auto time tracking duration) for not making progress during non-working hours. `est log` can also be used as an escape hatch, e.g. for a 3h project on a Saturday.
-	})
-	if err != nil {
-		fmt.Println("fatal: " + err.Error())
-		os.Exit(1)
-		return
+	// Set here rather than in rootCmd to avoid an initialization cycle.
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		core.SetConfigOverrides(core.ConfigOverrides{
+			ConfigFile: flagConfig,
+			Estfile:    flagEstfile,
+			Profile:    flagProfile,
+		})
+		checkIdle(cmd)
+	}
+	rootCmd.PersistentPostRun = func(cmd *cobra.Command, args []string) {
+		warnStale(cmd)
 	}
+	rootCmd.PersistentFlags().StringVar(&flagConfig, "config", "", "use this estconfig file (default $EST_CONFIG or ~/.config/est/estconfig.toml)")
+	rootCmd.PersistentFlags().StringVar(&flagEstfile, "estfile", "", "use this estfile (default $EST_FILE or the estfile in estconfig)")
+	rootCmd.PersistentFlags().StringVar(&flagProfile, "profile", "", "use this profile from estconfig (default $EST_PROFILE)")
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -54,3 +51,23 @@ func Execute() {
 		os.Exit(1)
 	}
 }
+
+// isCommand returns true iff passed command is one of the passed top-level
+// commands, e.g. isCommand(cmd, "help").
+func isCommand(cmd *cobra.Command, names ...string) bool {
+	if cmd.Parent() != rootCmd {
+		return false
+	}
+	for _, n := range names {
+		if cmd.Name() == n {
+			return true
+		}
+	}
+	return false
+}
+
+// commandDescription returns a description of the current invocation of est,
+// used to describe changes to the estfile, e.g. in 'est undo'.
+func commandDescription() string {
+	return strings.Join(append([]string{"est"}, os.Args[1:]...), " ")
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"strings"
 	"time"
@@ -31,6 +32,10 @@ est, because those are the tasks predicted by 'est schedule'. If many of your
 future tasks are not estimated in est, or the tasks estimated in est are never
 actually worked on, then the usefulness of 'est schedule' will be reduced.
 
+Each run records the 50%, 80% and 95% predicted dates, with the tasks in the
+schedule, so that you can see whether the schedule is slipping over time, see
+'est schedule history'.
+
 In future, 'est schedule' should allow selection of tasks to estimate, e.g.
 all tasks related to one project, and merging of estfiles for team scheduling.
 `,
@@ -48,9 +53,14 @@ func runSchedule() {
 		os.Stdout.WriteString("Predicting delivery schedule for unstarted, estimated tasks...")
 		rs := core.PadFakeHistoricalEstimateAccuracyRatios(
 			ef.HistoricalEstimateAccuracyRatios().Ratios(), ef.FakeHistoricalEstimateAccuracyRatios)
-		dates := core.DeliverySchedule(globalWorkTimes, now, rs, ts)
+		dates := core.DeliverySchedule(ec.WorkTimes(), now, rs, ts)
 		ss := core.RenderDeliverySchedule(dates)
 		os.Stdout.WriteString("done\n")
+		if len(ts) > 0 {
+			if err := ef.RecordSchedule(core.NewScheduleSnapshot(dates, ts, now), ec.WorkTimes().Location()); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: couldn't record schedule history: %v\n", err)
+			}
+		}
 		if scheduleDisplayDatesOnly {
 			s := strings.Join(ss[:], "\n") + "\n"
 			os.Stdout.WriteString(s)
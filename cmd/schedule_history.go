@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var scheduleHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show how predicted delivery dates moved over time",
+	Long: `Show how predicted delivery dates moved over time
+
+est schedule history [--since <date>] [--output <file.png|file.svg>]
+
+Show whether your schedule is slipping, from the 50%, 80% and 95% predicted
+delivery dates recorded by each run of 'est schedule'. Runs on the same day
+with the same tasks and estimates are recorded once, as the latest run.
+
+By default, the predicted dates are drawn in the terminal and then listed as a
+table, with:
+
+  DRIFT  how far the 50% predicted date moved since the previous schedule,
+         in calendar days, e.g. "+3d" if it slipped by three days
+  SCOPE  number of tasks added (+), removed (-) and re-estimated (~) since
+         the previous schedule, e.g. "+2 -1 ~0". Tasks are removed from the
+         schedule when they're started, done or deleted.
+
+With --output, the chart is written to the passed PNG or SVG file instead.
+--since includes only schedules recorded on or after the passed date.
+
+Examples:
+  # Show how predicted delivery dates moved.
+  est schedule history
+
+  # Show schedules recorded since Jan 1st, and write them to an SVG chart.
+  est schedule history --since 2018-01-01 -o schedule.svg
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			h, err := ef.ScheduleHistory()
+			if err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			if scheduleHistoryFlagSince != "" {
+				since, err := parseDate(scheduleHistoryFlagSince, "since date", ec.WorkTimes().Location())
+				if err != nil {
+					fmt.Println("fatal: " + err.Error())
+					os.Exit(1)
+					return
+				}
+				h = h.Since(since)
+			}
+			var summary bytes.Buffer
+			err = h.WriteTable(&summary)
+			if err == nil && len(h) > 0 {
+				// --dates-only is inherited from est schedule and ignored here.
+				switch {
+				case scheduleHistoryFlagOutput != "":
+					err = core.WriteScheduleHistoryChart(h, scheduleHistoryFlagOutput)
+				case isTerminal(os.Stdout):
+					err = core.ScheduleHistoryTerminalChart(h, h.RenderSummary())
+				}
+			}
+			if err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			os.Stdout.Write(summary.Bytes())
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+var scheduleHistoryFlagSince string
+var scheduleHistoryFlagOutput string
+
+func init() {
+	scheduleHistoryCmd.PersistentFlags().StringVar(&scheduleHistoryFlagSince, "since", "", "include only schedules recorded on or after this date, e.g. 2018-01-31")
+	scheduleHistoryCmd.PersistentFlags().StringVarP(&scheduleHistoryFlagOutput, "output", "o", "", "write chart to this PNG or SVG file, instead of drawing it in the terminal")
+	scheduleCmd.AddCommand(scheduleHistoryCmd)
+}
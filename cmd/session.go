@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "List and correct sessions of time worked on tasks",
+	Long: `List and correct sessions of time worked on tasks
+
+est session ls|edit|split|rm
+
+A task's actual time is recorded as sessions. Each session is an interval of
+time worked on the task, with a start, an end, and a duration credited to the
+task. A session's source is one of:
+
+  auto      tracked by auto time tracking while the task was started. Auto
+            sessions are bounded by working hours, and there's at most one
+            per task per day. Share is the fraction of working time credited
+            to the task, e.g. 0.5 if two tasks were started.
+  manual    logged with 'est log' or --log.
+  migrated  time tracked before est recorded sessions; start and end are
+            approximate.
+  imported  time imported by 'est import'; start and end are approximate.
+
+Use 'est session ls' to see when you worked on a task, and 'est session edit',
+'est session split' and 'est session rm' to correct one mistaken session, e.g.
+if a task was left started over lunch. Sessions are identified by a prefix of
+the session ID shown in 'est session ls'. Changes can be reverted with 'est
+undo'.
+
+Times are "2018-01-31 14:00", "2018-01-31 2:00pm", or RFC 3339, in local time.
+
+Examples:
+  # List sessions of the task with ID prefix "3c".
+  est session ls 3c
+
+  # Correct a session which was tracked until 5:30pm, but work stopped at 3pm.
+  est session edit 8d6d9 --end "2018-01-31 15:00"
+`,
+}
+
+func init() {
+	rootCmd.AddCommand(sessionCmd)
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var sessionEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Change the start, end, or duration of a session",
+	Long: `Change the start, end, or duration of a session
+
+est session edit <session ID prefix> [--start <time>] [--end <time>] [--duration <duration>]
+
+If the start or end is changed and --duration isn't passed, the duration is
+recalculated: for auto sessions, the working time between start and end times
+the session's share; otherwise, the time between start and end. See 'est help
+session'.
+
+Editing a session changes the task's actual time, but doesn't affect auto time
+tracking of a started task.
+
+Examples:
+  # Work stopped at 3pm, but the task was tracked until 5:30pm.
+  est session edit 8d6d9 --end "2018-01-31 15:00"
+
+  # Credit 45 minutes to a session.
+  est session edit 8d6d9 --duration 45m
+
+  # Credit nothing to a session, keeping a record of when the task was started.
+  est session edit 8d6d9 --duration 0
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 || sessionEditFlagStart == "" && sessionEditFlagEnd == "" && sessionEditFlagDuration == "" {
+			fmt.Println("usage: est session edit <session ID prefix> [--start <time>] [--end <time>] [--duration <duration>]")
+			os.Exit(1)
+			return
+		}
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			i, j := ef.Tasks.FindSessionByIDPrefix(args[0])
+			if i < 0 {
+				fmt.Printf("fatal: no session with ID prefix '%s'\n", args[0])
+				os.Exit(1)
+				return
+			}
+			t := ef.Tasks[i]
+			s := t.Sessions()[j]
+			var err error
+			if sessionEditFlagStart != "" {
+				if s.Start, err = parseTime(sessionEditFlagStart, "start time", time.Local); err != nil {
+					fmt.Println("fatal: " + err.Error())
+					os.Exit(1)
+					return
+				}
+			}
+			if sessionEditFlagEnd != "" {
+				if s.End, err = parseTime(sessionEditFlagEnd, "end time", time.Local); err != nil {
+					fmt.Println("fatal: " + err.Error())
+					os.Exit(1)
+					return
+				}
+			}
+			if sessionEditFlagDuration != "" {
+				if s.Duration, err = parseDurationHoursOrZero(sessionEditFlagDuration, "duration"); err != nil {
+					fmt.Println("fatal: " + err.Error())
+					os.Exit(1)
+					return
+				}
+			} else {
+				s.Duration = s.DefaultDuration(ec.WorkTimes())
+			}
+			if err := t.EditSession(j, s.Start, s.End, s.Duration); err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			if err := ef.Write(commandDescription()); err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			os.Stdout.WriteString(core.RenderSessions(ef.Tasks[i:i+1], time.Time{}))
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+var sessionEditFlagStart string
+var sessionEditFlagEnd string
+var sessionEditFlagDuration string
+
+func init() {
+	sessionEditCmd.PersistentFlags().StringVarP(&sessionEditFlagStart, "start", "s", "", "new start time, e.g. \"2018-01-31 14:00\"")
+	sessionEditCmd.PersistentFlags().StringVarP(&sessionEditFlagEnd, "end", "e", "", "new end time, e.g. \"2018-01-31 15:30\"")
+	sessionEditCmd.PersistentFlags().StringVarP(&sessionEditFlagDuration, "duration", "d", "", "new duration credited to the task, e.g. \"1.5h\" or \"90m\"")
+	sessionCmd.AddCommand(sessionEditCmd)
+}
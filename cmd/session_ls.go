@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var sessionLSCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List sessions of time worked on tasks",
+	Long: `List sessions of time worked on tasks
+
+est session ls [<task ID prefix>]
+
+List sessions of the task with the passed ID prefix, or of all tasks which
+aren't deleted, in order of start time. For one task, also list corrections to
+its actual time, see 'est help set-actual'. See 'est help session'.
+
+Examples:
+  # List sessions of the task with ID prefix "3c".
+  est session ls 3c
+
+  # List sessions of all tasks since January 31.
+  est session ls --since 2018-01-31
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 1 {
+			fmt.Println("usage: est session ls [<task ID prefix>]")
+			os.Exit(1)
+			return
+		}
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			ts := ef.Tasks.IsNotDeleted()
+			if len(args) > 0 {
+				i := ef.Tasks.FindByIDPrefix(args[0])
+				if i < 0 {
+					fmt.Printf("fatal: no task with ID prefix '%s'\n", args[0])
+					os.Exit(1)
+					return
+				}
+				ts = ef.Tasks[i : i+1]
+			}
+			var since time.Time
+			if sessionLSFlagSince != "" {
+				var err error
+				since, err = parseDate(sessionLSFlagSince, "since date", ec.WorkTimes().Location())
+				if err != nil {
+					fmt.Println("fatal: " + err.Error())
+					os.Exit(1)
+					return
+				}
+			}
+			os.Stdout.WriteString(core.RenderSessions(ts, since))
+			if len(args) > 0 {
+				if cs := ts[0].ActualCorrections(); len(cs) > 0 {
+					fmt.Println("\nCorrections to actual time:")
+					for _, c := range cs {
+						fmt.Println("  " + c)
+					}
+				}
+			}
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+var sessionLSFlagSince string
+
+func init() {
+	sessionLSCmd.PersistentFlags().StringVarP(&sessionLSFlagSince, "since", "s", "", "list only sessions since this date, e.g. 2018-01-31")
+	sessionCmd.AddCommand(sessionLSCmd)
+}
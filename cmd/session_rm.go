@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var sessionRMCmd = &cobra.Command{
+	Use:   "rm",
+	Short: "Remove a session",
+	Long: `Remove a session
+
+est session rm <session ID prefix>
+
+Remove a session, reducing the task's actual time by the session's duration.
+Use 'est session split' first to remove part of a session. A removed session
+can be restored with 'est undo'. See 'est help session'.
+
+Examples:
+  # Remove a session tracked by mistake.
+  est session rm 8d6d9
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			fmt.Println("usage: est session rm <session ID prefix>")
+			os.Exit(1)
+			return
+		}
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			i, j := ef.Tasks.FindSessionByIDPrefix(args[0])
+			if i < 0 {
+				fmt.Printf("fatal: no session with ID prefix '%s'\n", args[0])
+				os.Exit(1)
+				return
+			}
+			ef.Tasks[i].RemoveSession(j)
+			if err := ef.Write(commandDescription()); err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			os.Stdout.WriteString(core.RenderSessions(ef.Tasks[i:i+1], time.Time{}))
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+func init() {
+	sessionCmd.AddCommand(sessionRMCmd)
+}
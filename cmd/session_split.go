@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var sessionSplitCmd = &cobra.Command{
+	Use:   "split",
+	Short: "Split a session in two at a time",
+	Long: `Split a session in two at a time
+
+est session split <session ID prefix> <time>
+
+Split a session into two sessions, one ending and one starting at the passed
+time. The session's duration is divided in proportion to the working time
+before and after the split. Splitting doesn't change the task's actual time; it
+makes it possible to edit or remove part of a session. See 'est help session'.
+
+Examples:
+  # The task was left started over a 2pm-3pm meeting; remove the meeting.
+  est session split 8d6d9 "2018-01-31 14:00"
+  est session split <ID of new session> "2018-01-31 15:00"
+  est session rm <ID of session 2pm-3pm>
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 {
+			fmt.Println("usage: est session split <session ID prefix> <time>")
+			os.Exit(1)
+			return
+		}
+		at, err := parseTime(args[1], "split time", time.Local)
+		if err != nil {
+			fmt.Println("fatal: " + err.Error())
+			os.Exit(1)
+			return
+		}
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			i, j := ef.Tasks.FindSessionByIDPrefix(args[0])
+			if i < 0 {
+				fmt.Printf("fatal: no session with ID prefix '%s'\n", args[0])
+				os.Exit(1)
+				return
+			}
+			if err := ef.Tasks[i].SplitSession(ec.WorkTimes(), j, at); err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			if err := ef.Write(commandDescription()); err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			os.Stdout.WriteString(core.RenderSessions(ef.Tasks[i:i+1], time.Time{}))
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+func init() {
+	sessionCmd.AddCommand(sessionSplitCmd)
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var setActualCmd = &cobra.Command{
+	Use:   "set-actual",
+	Short: "Correct a task's actual time",
+	Long: `Correct a task's actual time
+
+est set-actual <task ID prefix> <duration>
+
+Set the actual time of a task, e.g. because auto time tracking over-credited
+the task while it was left started during a meeting, which would otherwise
+skew the estimate accuracy ratios used by 'est schedule'.
+
+Time is removed from the task's most recent sessions first, or added as a
+manual session ending now. Time is first tracked on started tasks up to now, so
+the duration is the task's actual time as of now. Each correction is recorded
+on the task and shown by 'est session ls <task ID prefix>'.
+
+To remove a known amount of time, use 'est log --subtract'. To correct when
+time was worked, use 'est session'.
+
+Examples:
+  # Set the actual time of the task with ID prefix "3c" to 4.5 hours.
+  est set-actual 3c 4.5h
+
+  # The task was started by mistake; clear its actual time.
+  est set-actual 3c 0
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 {
+			fmt.Println("usage: est set-actual <task ID prefix> <duration>")
+			os.Exit(1)
+			return
+		}
+		d, err := parseDurationHoursOrZero(args[1], "actual duration")
+		if err != nil {
+			fmt.Printf("fatal: %v\n", err)
+			os.Exit(1)
+			return
+		}
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			i := ef.Tasks.FindByIDPrefix(args[0])
+			if i < 0 {
+				fmt.Printf("fatal: no task with ID prefix '%s'\n", args[0])
+				os.Exit(1)
+				return
+			}
+			if err := ef.Tasks.SetActual(ec.WorkTimes(), i, d, time.Now()); err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			if err := ef.Write(commandDescription()); err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			fmt.Println(core.RenderTaskOneLineSummary(ef.Tasks[i], true))
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(setActualCmd)
+}
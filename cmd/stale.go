@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var staleCmd = &cobra.Command{
+	Use:   "stale",
+	Short: "Show started tasks which were probably forgotten",
+	Long: `Show started tasks which were probably forgotten
+
+est stale [--pause [<task ID prefix>]]
+
+Show started tasks which are stale, i.e. probably left started by mistake. Auto
+time tracking keeps adding working hours to a forgotten task, and its inflated
+actual time skews the estimate accuracy ratios used by 'est schedule'.
+
+A started task is stale if its actual time exceeds stalemultiple times its
+estimate (default 3), or if it has had no activity for stalehours working hours
+(default 16). Activity is anything done to the task with est, e.g. starting it
+or logging time; auto time tracking isn't activity. Set stalemultiple and
+stalehours in your estconfig.
+
+est warns about stale tasks after each command.
+
+With --pause, stale tasks are paused retroactively as of their last activity,
+removing time auto tracked on them since then. Pass a task ID prefix to pause
+only that task. Each retroactive pause is recorded on the task and shown by 'est
+session ls <task ID prefix>'.
+
+Examples:
+  # Show stale tasks.
+  est stale
+
+  # Pause all stale tasks as of their last activity.
+  est stale --pause
+
+  # Pause the stale task with ID prefix "3c" as of its last activity.
+  est stale --pause 3c
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 1 || (len(args) > 0 && !staleFlagPause) {
+			fmt.Println("usage: est stale [--pause [<task ID prefix>]]")
+			os.Exit(1)
+			return
+		}
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			now := time.Now()
+			ss := core.StaleTasks(ec.WorkTimes(), ef.Tasks, now, ec.StaleMultiple, ec.StaleHours)
+			if len(args) > 0 {
+				i := ef.Tasks.FindByIDPrefix(args[0])
+				if i < 0 {
+					fmt.Printf("fatal: no task with ID prefix '%s'\n", args[0])
+					os.Exit(1)
+					return
+				}
+				var ss2 []core.StaleTask
+				for _, s := range ss {
+					if s.Task == ef.Tasks[i] {
+						ss2 = append(ss2, s)
+					}
+				}
+				if len(ss2) == 0 {
+					fmt.Printf("fatal: task '%s' isn't stale\n", args[0])
+					os.Exit(1)
+					return
+				}
+				ss = ss2
+			}
+			if len(ss) == 0 {
+				fmt.Println("no stale tasks")
+				return
+			}
+			os.Stdout.WriteString(core.RenderStaleTasks(ss))
+			if !staleFlagPause {
+				fmt.Println("\nTo pause stale tasks as of their last activity, run 'est stale --pause'.")
+				return
+			}
+			for _, s := range ss {
+				i := ef.Tasks.FindByIDPrefix(s.Task.ID().String())
+				if err := ef.Tasks.PauseAsOf(ec.WorkTimes(), i, s.LastActivity, now); err != nil {
+					fmt.Printf("fatal: %v\n", err)
+					os.Exit(1)
+					return
+				}
+			}
+			if err := ef.Write(commandDescription()); err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+			for j, s := range ss {
+				fmt.Println(core.RenderTaskOneLineSummary(s.Task, j == 0))
+			}
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+// warnStale writes a warning to stderr if any started tasks are stale. It's
+// run after each command, except commands which don't use the estfile, and
+// shares the estfile already loaded by the command.
+func warnStale(cmd *cobra.Command) {
+	if isCommand(cmd, "stale", "prompt", "bash", "help") {
+		return
+	}
+	core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+		ss := core.StaleTasks(ec.WorkTimes(), ef.Tasks, time.Now(), ec.StaleMultiple, ec.StaleHours)
+		if len(ss) == 0 {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "warning: %d started tasks may have been forgotten, see 'est stale':\n%s", len(ss), core.RenderStaleTasks(ss))
+	}, func() {
+		// failed to load estconfig or estfile. Err printed elsewhere.
+	})
+}
+
+var staleFlagPause bool
+
+func init() {
+	staleCmd.PersistentFlags().BoolVar(&staleFlagPause, "pause", false, "pause stale tasks as of their last activity")
+	rootCmd.AddCommand(staleCmd)
+}
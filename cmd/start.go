@@ -23,7 +23,9 @@ ID shown in 'est ls'.
 A summary of started tasks is shown in the est prompt. See 'est help prompt'.
 
 The start time can be in the past with -a, using the same duration syntax as the
-estimate command.
+estimate command, or with --at, using an absolute time such as "9:40am",
+"yesterday 4pm" or "2018-01-31 14:00" in local time. The start time can't be
+before the task's most recent activity, e.g. when it was last paused.
 
 An estimate can be provided with -e, otherwise the task must already be
 estimated to be started.
@@ -32,6 +34,12 @@ Multiple tasks can be started concurrently with -m, otherwise any current task
 will be paused when starting a new task. See 'est help done' for an explanation
 of how time is automatically tracked with multiple started tasks.
 
+While multiple tasks are started, time is shared among them in proportion to
+their focus weights, set with -w. The default weight is 1. For example, a main
+task and a task started with -w 0.2 get 83% and 17% of the time. A task keeps
+its weight until it's set again; -w on a started task changes its weight from
+now on. Weights are shown in 'est ls' and the est prompt.
+
 Tasks cannot be paused directly. Paused tasks can be restarted, marked done,
 deleted, or have time tracked using 'est log'.
 
@@ -45,11 +53,20 @@ Examples:
   # Start as of forty five minutes ago the task with ID prefix "813".
   est s -a 45m 813
 
+  # Start as of 9:40am today the task with ID prefix "813".
+  est s --at 9:40am 813
+
   # Estimate at thirty minutes and start the task with ID prefix "f6c".
   est s -e 30m f6c
 
   # Start the task with ID prefix "8a" such that multiple tasks are now started.
   est s -m 8a
+
+  # Start the task with ID prefix "d1" alongside other tasks, with low focus.
+  est s -m -w 0.2 d1
+
+  # Change the weight of the started task with ID prefix "d1".
+  est s -w 0.5 d1
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) != 1 {
@@ -70,6 +87,28 @@ Examples:
 				os.Exit(1)
 				return
 			}
+			weightChanged := cmd.Flags().Changed("weight")
+			if weightChanged && ef.Tasks[i].IsStarted() {
+				// Change the weight of a started task, rather than starting it.
+				// Time is tracked on all started tasks up to the new weight's
+				// start, so it mustn't precede any of their activity.
+				at := applyTimeFlags(time.Now())
+				for _, t := range ef.Tasks.IsStarted() {
+					checkTimeFlags(t, at)
+				}
+				if err := ef.Tasks.SetWeight(ec.WorkTimes(), i, startFlagWeight, at); err != nil {
+					fmt.Printf("fatal: %v\n", err)
+					os.Exit(1)
+					return
+				}
+				if err := ef.Write(commandDescription()); err != nil {
+					fmt.Printf("fatal: %v\n", err)
+					os.Exit(1)
+					return
+				}
+				fmt.Println(core.RenderTaskOneLineSummary(ef.Tasks[i], true))
+				return
+			}
 			if estimate != 0 {
 				if err := ef.Tasks[i].SetEstimated(estimate); err != nil {
 					fmt.Printf("fatal: %v\n", err)
@@ -77,24 +116,30 @@ Examples:
 					return
 				}
 			}
-			startTime := applyFlagAgo(time.Now())
-			if err := doFlagMultiple(ef, globalWorkTimes, startTime); err != nil {
-				fmt.Printf("fatal: %v\n", err)
-				os.Exit(1)
-				return
+			startTime := applyTimeFlags(time.Now())
+			checkTimeFlags(ef.Tasks[i], startTime)
+			if weightChanged {
+				if err := ef.Tasks.SetWeight(ec.WorkTimes(), i, startFlagWeight, startTime); err != nil {
+					fmt.Printf("fatal: %v\n", err)
+					os.Exit(1)
+					return
+				}
 			}
-			if err := ef.Tasks.Start(globalWorkTimes, i, startTime); err != nil {
+			if err := startTask(ef, ec.WorkTimes(), i, startTime); err != nil {
 				fmt.Printf("fatal: %v\n", err)
 				os.Exit(1)
 				return
 			}
 			doFlagLog(ef.Tasks[i], startTime)
-			if err := ef.Write(); err != nil {
+			if err := ef.Write(commandDescription()); err != nil {
 				fmt.Printf("fatal: %v\n", err)
 				os.Exit(1)
 				return
 			}
 			fmt.Println(core.RenderTaskOneLineSummary(ef.Tasks[i], true))
+			if estimate != 0 {
+				doFlagSuggest(ef, ef.Tasks[i].Name(), estimate)
+			}
 		}, func() {
 			// failed to load estconfig or estfile. Err printed elsewhere.
 			os.Exit(1)
@@ -102,10 +147,15 @@ Examples:
 	},
 }
 
+var startFlagWeight float64 // focus weight of started task, used only if set
+
 func init() {
 	startCmd.PersistentFlags().BoolVarP(&flagMultiple, "multiple", "m", false, "allow multiple started tasks")
 	startCmd.PersistentFlags().StringVarP(&flagEstimate, "estimate", "e", "", "estimate this task before starting")
+	startCmd.PersistentFlags().BoolVar(&flagNoSuggest, "no-suggest", false, "don't suggest a calibrated estimate from history")
 	startCmd.PersistentFlags().StringVarP(&flagLog, "log", "l", "", "log time worked after starting this task")
 	startCmd.PersistentFlags().StringVarP(&flagAgo, "ago", "a", "", "start duration ago from now")
+	startCmd.PersistentFlags().StringVar(&flagAt, "at", "", "start at this time, e.g. 9:40am, \"yesterday 4pm\" or \"2018-01-31 14:00\"")
+	startCmd.PersistentFlags().Float64VarP(&startFlagWeight, "weight", "w", 0, "focus weight of this task while multiple tasks are started (default 1)")
 	rootCmd.AddCommand(startCmd)
 }
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show statistics of the accuracy of historical estimates",
+	Long: `Show statistics of the accuracy of historical estimates
+
+est stats [--since <date>] [--tag <tag>] [--weeks <n>] [--format table|json]
+
+Show numbers to track how well calibrated your estimates are over time,
+computed from the accuracy ratios of done tasks, including archived tasks. An
+accuracy ratio is estimated hours / actual hours, so a ratio below 1 is a task
+which took longer than estimated.
+
+  median ratio          the middle ratio
+  geometric mean ratio  the typical ratio, such that estimates 2x too high and
+                        2x too low cancel out
+  within ±20%, ±50%     share of tasks whose actual time was within 20% (50%)
+                        of their estimate, e.g. ratios between 0.83 and 1.2
+  bias                  "underestimate" if tasks typically take more than 5%
+                        longer than estimated, "overestimate" if less, else
+                        "none"
+
+These are shown for all estimates, for each of the last --weeks weeks (default
+8, starting Monday, by when tasks were done) to show the trend, and for each
+size of estimate: <1h, 1-4h, 4-16h and >16h.
+
+--since includes only tasks done on or after the passed date. --tag includes
+only tasks with the passed tag.
+
+Examples:
+  # Accuracy statistics of all estimates.
+  est stats
+
+  # Accuracy statistics of tasks tagged #auth done this year, as JSON.
+  est stats --since 2018-01-01 --tag auth --format json
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if statsFlagFormat != "table" && statsFlagFormat != "json" {
+			fmt.Println("fatal: format must be table or json")
+			os.Exit(1)
+			return
+		}
+		if statsFlagWeeks < 0 {
+			fmt.Println("fatal: weeks cannot be negative")
+			os.Exit(1)
+			return
+		}
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			ars := ef.HistoricalEstimateAccuracyRatios()
+			if statsFlagSince != "" {
+				since, err := parseDate(statsFlagSince, "since date", ec.WorkTimes().Location())
+				if err != nil {
+					fmt.Println("fatal: " + err.Error())
+					os.Exit(1)
+					return
+				}
+				ars = ars.Since(since)
+			}
+			if statsFlagTag != "" {
+				ars = ars.HasTag(statsFlagTag)
+			}
+			s := core.NewAccuracyStats(ec.WorkTimes(), ars, statsFlagWeeks, time.Now())
+			var err error
+			if statsFlagFormat == "json" {
+				err = s.WriteJSON(os.Stdout)
+			} else {
+				err = s.WriteTable(os.Stdout)
+			}
+			if err != nil {
+				fmt.Printf("fatal: %v\n", err)
+				os.Exit(1)
+				return
+			}
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+var statsFlagSince string
+var statsFlagTag string
+var statsFlagWeeks int
+var statsFlagFormat string
+
+func init() {
+	statsCmd.PersistentFlags().StringVar(&statsFlagSince, "since", "", "include only tasks done on or after this date, e.g. 2018-01-31")
+	statsCmd.PersistentFlags().StringVarP(&statsFlagTag, "tag", "t", "", "include only tasks with this tag")
+	statsCmd.PersistentFlags().IntVarP(&statsFlagWeeks, "weeks", "w", 8, "number of weeks in trend")
+	statsCmd.PersistentFlags().StringVarP(&statsFlagFormat, "format", "f", "table", "output format, table or json")
+	rootCmd.AddCommand(statsCmd)
+}
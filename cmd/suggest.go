@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var suggestCmd = &cobra.Command{
+	Use:   "suggest",
+	Short: "Suggest an estimate from done tasks with similar names",
+	Long: `Suggest an estimate from done tasks with similar names
+
+est suggest <task name>
+
+List the done tasks whose names are most similar to the passed task name, with
+what they were estimated at and how long they actually took, and suggest an
+estimate range from their actual times. The task name is the concatenation of
+all non-flag args, no quotes required, as in 'est add'.
+
+Names are compared by their words, ignoring case and common words such as
+"the". Words which are rare among your done tasks, e.g. "settings", count for
+more than common ones, e.g. "fix". Tags count as words.
+
+'est add' shows the suggested estimate for a new unestimated task, unless
+--no-suggest.
+
+Examples:
+  # Suggest an estimate for a task named "add button to settings page".
+  est suggest add button to settings page
+
+  # Show the ten most similar done tasks.
+  est suggest -n 10 add button to settings page
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		name := strings.TrimSpace(strings.Join(args, " "))
+		if len(name) < 1 {
+			fmt.Println("usage: est suggest <task name>")
+			os.Exit(1)
+			return
+		}
+		if suggestFlagN < 1 {
+			fmt.Println("fatal: number of similar tasks must be at least 1")
+			os.Exit(1)
+			return
+		}
+		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+			ss := core.SimilarDoneTasks(ef.Tasks, name, suggestFlagN)
+			s, ok := core.SuggestEstimate(ss)
+			if !ok {
+				fmt.Println("no similar done tasks")
+				return
+			}
+			os.Stdout.WriteString(core.RenderSimilarTasks(ss))
+			fmt.Println("\n" + s.Render())
+		}, func() {
+			// failed to load estconfig or estfile. Err printed elsewhere.
+			os.Exit(1)
+		})
+	},
+}
+
+var suggestFlagN int
+
+func init() {
+	suggestCmd.PersistentFlags().IntVarP(&suggestFlagN, "number", "n", 5, "number of similar done tasks to show")
+	rootCmd.AddCommand(suggestCmd)
+}
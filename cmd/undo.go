@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/ryanberckmans/est/core"
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo the most recent changes to your estfile",
+	Long: `Undo the most recent changes to your estfile
+
+est undo [<number of changes>]
+
+Revert your estfile to its state prior to the most recent command which changed
+it, such as 'est done', 'est rm', or 'est log'. By default one change is undone;
+pass a number to undo that many changes.
+
+Undo restores the entire estfile, including actual time tracked automatically
+by est, so the result is exactly as if the undone commands never ran. Changes
+made automatically by est, such as pausing idle tasks (see 'est help idle'),
+are undone along with the command before them.
+
+Undone changes can be reapplied with 'est redo', until another command changes
+the estfile. The most recent 50 changes can be undone.
+
+Examples:
+  # Undo the most recent change, e.g. 'est done' on the wrong task.
+  est undo
+
+  # Undo the three most recent changes.
+  est undo 3
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runUndoOrRedo(args, "undo", "Undid", (*core.EstFile).Undo)
+	},
+}
+
+// runUndoOrRedo is shared by 'est undo' and 'est redo'.
+func runUndoOrRedo(args []string, name string, verb string, fn func(ef *core.EstFile, n int) ([]string, error)) {
+	if len(args) > 1 {
+		fmt.Printf("usage: est %s [<number of changes>]\n", name)
+		os.Exit(1)
+		return
+	}
+	n := 1
+	if len(args) == 1 {
+		var err error
+		n, err = strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			fmt.Println("fatal: number of changes must be a positive integer")
+			os.Exit(1)
+			return
+		}
+	}
+	core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
+		before := *ef
+		ds, err := fn(ef, n)
+		if err != nil {
+			fmt.Printf("fatal: %v\n", err)
+			os.Exit(1)
+			return
+		}
+		for _, d := range ds {
+			fmt.Printf("%s: %s\n", verb, d)
+		}
+		os.Stdout.WriteString(core.RenderChangedTasks(before, *ef))
+	}, func() {
+		// failed to load estconfig or estfile. Err printed elsewhere.
+		os.Exit(1)
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+}
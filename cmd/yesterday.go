@@ -14,25 +14,29 @@ var yesterdayCmd = &cobra.Command{
 	Short:   "Show yesterday's activity",
 	Long: `Show yesterday's activity
 
-est yesterday [--ago <duration>]
+est yesterday [--ago <duration> | --at <date>]
 
 Show task activity yesterday, where yesterday is defined as the most recent day
 with any working hours prior to or including yesterday's calendar date.
 
 Yesterday's calendar date can be further in past with --ago. Note that --ago
 uses the same syntax as 'est estimate'; supported units are minutes and hours,
-so typically you'll want a multiple of 24 hours.
+so typically you'll want a multiple of 24 hours. Alternatively, --at shows the
+activity as of a date, e.g. the most recent working day before 2018-01-31.
 
 Examples:
   # Show task activity three days ago
   est y -a48h # this is only 48h, not 72h, because the first 24h is a base
 
+  # Show task activity on the working day before Wednesday January 31
+  est y --at 2018-01-31
+
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
 			ts := ef.Tasks.SortByStatusDescending()
-			now := applyFlagAgo(time.Now())
-			os.Stdout.WriteString(core.RenderYesterdayTasks(globalWorkTimes, ts, now))
+			now := applyTimeFlags(time.Now())
+			os.Stdout.WriteString(core.RenderYesterdayTasks(ec.WorkTimes(), ts, now))
 		}, func() {
 			// failed to load estconfig or estfile. Err printed elsewhere.
 			os.Exit(1)
@@ -42,5 +46,6 @@ Examples:
 
 func init() {
 	yesterdayCmd.PersistentFlags().StringVarP(&flagAgo, "ago", "a", "", "show activity from one business day prior to today's calendar date minus duration ago")
+	yesterdayCmd.PersistentFlags().StringVar(&flagAt, "at", "", "show activity from one business day prior to this date, e.g. 2018-01-31")
 	rootCmd.AddCommand(yesterdayCmd)
 }
@@ -1,7 +1,9 @@
 package core
 
 import (
+	"fmt"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -11,6 +13,7 @@ type AccuracyRatio struct {
 	time     time.Time     // anonymous time of this data point, e.g. task done date
 	duration time.Duration // anonymous duration of this data point, e.g. task estimate
 	ratio    float64       // data point, ratio of (expected, actual)
+	tags     []string      // tags of the task from which this data point came, see Task.Tags()
 }
 
 // AccuracyRatios provides convenience functions.
@@ -38,6 +41,75 @@ func (ars AccuracyRatios) After(t time.Time) AccuracyRatios {
 	})
 }
 
+// Since returns subset of accuracy ratios which are at or after the passed time.
+func (ars AccuracyRatios) Since(t time.Time) AccuracyRatios {
+	return filterAccuracyRatios(ars, func(ar *AccuracyRatio) bool {
+		return !ar.time.Before(t)
+	})
+}
+
+// HasTag returns subset of accuracy ratios which have the passed tag.
+func (ars AccuracyRatios) HasTag(tag string) AccuracyRatios {
+	tag2 := strings.ToLower(strings.TrimPrefix(tag, "#"))
+	return filterAccuracyRatios(ars, func(ar *AccuracyRatio) bool {
+		for _, t := range ar.tags {
+			if t == tag2 {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// RenderAccuracyRatioSummary returns a user-suitable text summary of the
+// accuracy of passed accuracy ratios, grouped by the bands shown as colors
+// in AccuracyRatioChart().
+func RenderAccuracyRatioSummary(ars AccuracyRatios) string {
+	if len(ars) == 0 {
+		return "No estimates.\n"
+	}
+	var counts [4]int
+	rs := make([]float64, len(ars))
+	for i, ar := range ars {
+		counts[accuracyRatioBand(ar.ratio)]++
+		rs[i] = ar.ratio
+	}
+	sort.Float64s(rs)
+	m := median(rs)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d estimates:\n", len(ars))
+	for i, label := range []string{"within 20%", "within 40%", "within 2x", "off by 2x or more"} {
+		fmt.Fprintf(&b, "  %-18s %3d (%.0f%%)\n", label+":", counts[i], 100*float64(counts[i])/float64(len(ars)))
+	}
+	fmt.Fprintf(&b, "Median accuracy ratio %.2f, i.e. tasks typically take %.2fx their estimate.\n", m, 1/m)
+	return b.String()
+}
+
+// archivedAccuracyRatio is a serializable AccuracyRatio, stored in an
+// estfile after the task from which it came was archived.
+type archivedAccuracyRatio struct {
+	DoneAt    time.Time
+	Estimated time.Duration
+	Ratio     float64
+	Tags      []string
+}
+
+func toArchivedAccuracyRatios(ars AccuracyRatios) []archivedAccuracyRatio {
+	ars2 := make([]archivedAccuracyRatio, len(ars))
+	for i, ar := range ars {
+		ars2[i] = archivedAccuracyRatio{DoneAt: ar.time, Estimated: ar.duration, Ratio: ar.ratio, Tags: ar.tags}
+	}
+	return ars2
+}
+
+func fromArchivedAccuracyRatios(ars []archivedAccuracyRatio) AccuracyRatios {
+	ars2 := make(AccuracyRatios, len(ars))
+	for i, ar := range ars {
+		ars2[i] = AccuracyRatio{time: ar.DoneAt, duration: ar.Estimated, ratio: ar.Ratio, tags: ar.Tags}
+	}
+	return ars2
+}
+
 func filterAccuracyRatios(ars AccuracyRatios, fn func(t *AccuracyRatio) bool) AccuracyRatios {
 	if ars == nil {
 		return nil
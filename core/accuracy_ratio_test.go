@@ -0,0 +1,42 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccuracyRatioSummaryAndChart(t *testing.T) {
+	now := time.Date(2018, 1, 31, 10, 0, 0, 0, time.Local)
+	ars := AccuracyRatios{
+		{time: now.AddDate(0, 0, -20), duration: time.Hour, ratio: 1.1},
+		{time: now.AddDate(0, 0, -10), duration: 2 * time.Hour, ratio: 0.5},
+		{time: now.AddDate(0, 0, -5), duration: time.Hour, ratio: 0.8},
+		{time: now, duration: 3 * time.Hour, ratio: 0.25},
+	}
+	assert.Equal(t, []int{0, 1, 2, 3, 3}, []int{accuracyRatioBand(1.1), accuracyRatioBand(1 / 1.3), accuracyRatioBand(1.5), accuracyRatioBand(2), accuracyRatioBand(0.25)})
+
+	s := RenderAccuracyRatioSummary(ars)
+	assert.True(t, strings.HasPrefix(s, "4 estimates:\n"))
+	assert.Contains(t, s, "within 20%:          1 (25%)")
+	assert.Contains(t, s, "off by 2x or more:   2 (50%)")
+	assert.Contains(t, s, "Median accuracy ratio 0.65")
+
+	dir, err := ioutil.TempDir("", "est-chart-test")
+	if err != nil {
+		panic(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	svg := filepath.Join(dir, "accuracy.svg")
+	assert.NoError(t, WriteAccuracyRatioChart(ars, now, svg))
+	b, err := ioutil.ReadFile(svg)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(b), "<svg"))
+	assert.Error(t, WriteAccuracyRatioChart(ars, now, filepath.Join(dir, "accuracy.jpg")), "unsupported format")
+	assert.Error(t, WriteAccuracyRatioChart(nil, now, svg), "no accuracy ratios")
+}
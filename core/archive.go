@@ -0,0 +1,106 @@
+package core
+
+import (
+	"errors"
+	"time"
+)
+
+// archiveFile holds archived tasks for one estfile. It's stored in a sidecar
+// file so that archived tasks aren't loaded on every invocation of est.
+type archiveFile struct {
+	Tasks []task
+}
+
+// Archive moves done and deleted tasks, which were last done or deleted
+// before the passed time, from this EstFile to its archive file. Accuracy
+// ratios of archived tasks are kept in this EstFile, so that archived tasks
+// remain evidence for 'est schedule'. Returns the archived tasks. The archive
+// file is written when this EstFile is written, after the estfile, and is
+// restored if that write is undone, see Write().
+func (ef *EstFile) Archive(before time.Time) (tasks, error) {
+	if ef.storage == nil {
+		return nil, errors.New("estFile.storage was nil")
+	}
+	var archived, kept tasks
+	for _, t := range ef.Tasks {
+		status, statusTime := t.status()
+		if (status == taskStatusDone || status == taskStatusDeleted) && statusTime.Before(before) {
+			archived = append(archived, t)
+		} else {
+			kept = append(kept, t)
+		}
+	}
+	if len(archived) == 0 {
+		return nil, nil
+	}
+	ef.ArchivedEstimateAccuracyRatios = append(ef.ArchivedEstimateAccuracyRatios, historicalEstimateAccuracyRatios(archived)...)
+	ef.Tasks = kept
+	ef.archived = append(ef.archived, archived...)
+	return archived, nil
+}
+
+// appendToArchiveFile adds passed tasks to passed archive file.
+func appendToArchiveFile(archiveFileName string, archived tasks) error {
+	af, err := getArchiveFile(archiveFileName)
+	if err != nil {
+		return err
+	}
+	af.add(toUnexportedTasks(archived))
+	return writeSidecar(archiveFileName, af)
+}
+
+// add adds passed tasks to this archive file. Tasks may already be in the
+// archive file, e.g. if the estfile was restored from a backup, in which case
+// they're replaced.
+func (af *archiveFile) add(ts []task) {
+	if len(ts) == 0 {
+		return
+	}
+	ids := make([]string, len(ts))
+	for i, t := range ts {
+		ids[i] = t.ID.String()
+	}
+	af.remove(ids)
+	af.Tasks = append(af.Tasks, ts...)
+}
+
+// remove removes tasks with passed IDs from this archive file.
+func (af *archiveFile) remove(ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	removed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		removed[id] = true
+	}
+	ts := make([]task, 0, len(af.Tasks))
+	for _, t := range af.Tasks {
+		if !removed[t.ID.String()] {
+			ts = append(ts, t)
+		}
+	}
+	af.Tasks = ts
+}
+
+// ArchivedTasks returns the tasks in this EstFile's archive file, see Archive().
+func (ef EstFile) ArchivedTasks() (tasks, error) {
+	if ef.storage == nil {
+		return nil, errors.New("estFile.storage was nil")
+	}
+	af, err := getArchiveFile(sidecarFileName(ef.storage.FileName(), "archive"))
+	if err != nil {
+		return nil, err
+	}
+	return toExportedTasks(af.Tasks), nil
+}
+
+func getArchiveFile(archiveFileName string) (archiveFile, error) {
+	af := archiveFile{}
+	if err := readSidecar(archiveFileName, &af); err != nil {
+		return af, err
+	}
+	for i := range af.Tasks {
+		af.Tasks[i].addLegacySession(SessionSourceMigrated)
+	}
+	return af, nil
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchive(t *testing.T) {
+	ef, cleanup := newTestEstFile(t)
+	defer cleanup()
+
+	now := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+	newDoneTask := func(name string, doneAt time.Time) *Task {
+		tk := NewTask()
+		tk.task.Name = name
+		tk.task.Estimated = 2 * time.Hour
+		tk.task.Sessions = []Session{newSession(doneAt.Add(-4*time.Hour), doneAt, 4*time.Hour, 1, SessionSourceManual)}
+		tk.task.StartedAt = doneAt.Add(-4 * time.Hour)
+		tk.task.ActualUpdatedAt = doneAt
+		tk.task.IsDone = true
+		tk.task.DoneAt = doneAt
+		return tk
+	}
+	old := newDoneTask("old #x", now.AddDate(0, -2, 0))
+	recent := newDoneTask("recent", now)
+	open := NewTask()
+	ef.Tasks = tasks{old, recent, open}
+	assert.NoError(t, ef.Write("est add"))
+	ratiosBefore := ef.HistoricalEstimateAccuracyRatios()
+
+	ts, err := ef.Archive(now.AddDate(0, -1, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, tasks{old}, ts)
+	assert.Equal(t, tasks{recent, open}, ef.Tasks)
+	assert.Equal(t, ratiosBefore.Ratios(), ef.HistoricalEstimateAccuracyRatios().Ratios(), "archived tasks remain evidence")
+	assert.Equal(t, 1, len(ef.HistoricalEstimateAccuracyRatios().HasTag("x")), "archived ratios keep tags")
+
+	assert.NoError(t, ef.Write("est archive"))
+	ef1, err := getEstFile(ef.storage)
+	assert.NoError(t, err)
+	ef2 := toExportedEstfile(ef1)
+	assert.Equal(t, 2, len(ef2.HistoricalEstimateAccuracyRatios()), "archived ratios are written to estfile")
+	as, err := ef.ArchivedTasks()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(as))
+	assert.Equal(t, old.ID(), as[0].ID())
+
+	// Undoing an archive restores the archive file, so that archived tasks
+	// aren't in both the estfile and the archive file.
+	_, err = ef.Undo(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(ef.Tasks))
+	as, err = ef.ArchivedTasks()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(as))
+	_, err = ef.Redo(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ef.Tasks))
+	as, err = ef.ArchivedTasks()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(as))
+
+	// Archiving again after undo doesn't duplicate archived tasks.
+	_, err = ef.Undo(1)
+	assert.NoError(t, err)
+	_, err = ef.Archive(now.AddDate(0, -1, 0))
+	assert.NoError(t, err)
+	as, err = ef.ArchivedTasks()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(as), "archive file is written by Write")
+	assert.NoError(t, ef.Write("est archive"))
+	as, err = ef.ArchivedTasks()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(as))
+}
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ryanberckmans/est/core/worktimes"
+)
+
+// Burndown is the remaining estimated work of a backlog over time, and the
+// work added to and completed from it each week, reconstructed from when
+// tasks were estimated, done and deleted. See NewBurndown().
+type Burndown struct {
+	Days      []BurndownDay      // oldest first, up to and including today
+	Weeks     []BurndownWeek     // oldest first, starting Monday
+	Projected *BurndownProjected // projected completion of remaining work, nil if not projected, see Project()
+}
+
+// BurndownDay is the backlog at the end of one day.
+type BurndownDay struct {
+	Date      time.Time     // start of this day
+	Remaining time.Duration // estimated work of tasks in the backlog
+	Completed time.Duration // estimated work of tasks done since the start of the burndown, for a burn-up
+}
+
+// BurndownWeek is the work added to and completed from the backlog in one week.
+type BurndownWeek struct {
+	Start     time.Time     // start of Monday of this week
+	Added     time.Duration // estimated work of tasks estimated this week
+	Completed time.Duration // estimated work of tasks done this week
+}
+
+// BurndownProjected is the projected range of dates on which the remaining
+// work of a backlog will be done, see Project().
+type BurndownProjected struct {
+	Low    time.Time // 10% likely to be done by this date
+	Median time.Time // 50% likely
+	High   time.Time // 90% likely
+}
+
+// burndownSpan returns the times at which passed task was added to and
+// removed from the backlog, and whether its removal completed it. removed is
+// zero if the task is still in the backlog.
+func burndownSpan(t *Task) (added, removed time.Time, completed bool) {
+	added = t.EstimatedAt()
+	if added.IsZero() {
+		added = t.CreatedAt() // tasks estimated before EstimatedAt existed
+	}
+	if t.IsDone() {
+		removed, completed = t.DoneAt(), true
+		if removed.IsZero() {
+			removed = t.task.ActualUpdatedAt // tasks done before DoneAt existed
+		}
+	}
+	if t.IsDeleted() {
+		// deleted tasks were never completed, even if they were done
+		removed, completed = t.DeletedAt(), false
+	}
+	return added, removed, completed
+}
+
+// NewBurndown returns the burndown of passed estimated tasks from the start
+// of the day of passed since to passed now, in passed working hours'
+// timezone. A task is in the backlog from when it was last estimated until
+// it was done or deleted. Archived tasks aren't in the burndown, because
+// when they were estimated isn't kept.
+func NewBurndown(wt worktimes.WorkTimes, ts tasks, since, now time.Time) Burndown {
+	ts = ts.IsEstimated()
+	now = now.In(wt.Location())
+	start := worktimes.StartOfDay(since.In(wt.Location()))
+	today := worktimes.StartOfDay(now)
+
+	var b Burndown
+	for day := start; !day.After(today); day = day.AddDate(0, 0, 1) {
+		end := day.AddDate(0, 0, 1)
+		if end.After(now) {
+			end = now
+		}
+		d := BurndownDay{Date: day}
+		for _, t := range ts {
+			added, removed, completed := burndownSpan(t)
+			if added.After(end) {
+				continue
+			}
+			if removed.IsZero() || removed.After(end) {
+				d.Remaining += t.Estimated()
+			} else if completed && !removed.Before(start) {
+				d.Completed += t.Estimated()
+			}
+		}
+		b.Days = append(b.Days, d)
+	}
+
+	monday := start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	for week := monday; !week.After(today); week = week.AddDate(0, 0, 7) {
+		end := week.AddDate(0, 0, 7)
+		w := BurndownWeek{Start: week}
+		for _, t := range ts {
+			added, removed, completed := burndownSpan(t)
+			if !added.Before(week) && added.Before(end) {
+				w.Added += t.Estimated()
+			}
+			if completed && !removed.Before(week) && removed.Before(end) {
+				w.Completed += t.Estimated()
+			}
+		}
+		b.Weeks = append(b.Weeks, w)
+	}
+	return b
+}
+
+// Project sets the projected completion of this burndown's remaining work
+// from passed delivery schedule of the remaining tasks, see
+// DeliverySchedule().
+func (b *Burndown) Project(dates [100]time.Time) {
+	b.Projected = &BurndownProjected{Low: dates[9], Median: dates[49], High: dates[89]}
+}
+
+// Remaining returns the estimated work remaining in this burndown's backlog
+// as of its last day.
+func (b Burndown) Remaining() time.Duration {
+	if len(b.Days) == 0 {
+		return 0
+	}
+	return b.Days[len(b.Days)-1].Remaining
+}
+
+// remainingAt returns the estimated work remaining at the end of the day
+// before passed time, or zero if that's before this burndown.
+func (b Burndown) remainingAt(t time.Time) time.Duration {
+	var r time.Duration
+	for _, d := range b.Days {
+		if d.Date.After(t) {
+			break
+		}
+		r = d.Remaining
+	}
+	return r
+}
+
+// RenderProjected returns a user-suitable one line summary of the projected
+// completion of this burndown's remaining work.
+func (b Burndown) RenderProjected() string {
+	if b.Remaining() == 0 {
+		return "no remaining estimated work"
+	}
+	if b.Projected == nil {
+		return fmt.Sprintf("%.1fh remaining estimated work", b.Remaining().Hours())
+	}
+	return fmt.Sprintf("%.1fh remaining estimated work, projected done %s (10%%), %s (50%%), %s (90%%)",
+		b.Remaining().Hours(), b.Projected.Low.Format("Jan 2"), b.Projected.Median.Format("Jan 2"), b.Projected.High.Format("Jan 2"))
+}
+
+// WriteTable writes this burndown by week as aligned columns, for humans.
+func (b Burndown) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "WEEK\tADDED\tCOMPLETED\tREMAINING\n")
+	for _, wk := range b.Weeks {
+		// remaining at the end of this week, or as of today for this week
+		fmt.Fprintf(tw, "%s\t%.1fh\t%.1fh\t%.1fh\n", wk.Start.Format("2006-01-02"), wk.Added.Hours(), wk.Completed.Hours(),
+			b.remainingAt(wk.Start.AddDate(0, 0, 6)).Hours())
+	}
+	fmt.Fprintf(tw, "\n%s\n", b.RenderProjected())
+	return tw.Flush()
+}
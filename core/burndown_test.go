@@ -0,0 +1,92 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ryanberckmans/est/core/worktimes"
+)
+
+func TestBurndown(t *testing.T) {
+	wt := worktimes.GetAnonymousWorkTimes()
+	// Monday 2018-01-01, 10am
+	mon := time.Date(2018, 1, 1, 10, 0, 0, 0, time.Local)
+	estimated := func(estimate time.Duration, at time.Time) *Task {
+		tk := NewTask()
+		tk.task.Estimated = estimate
+		tk.task.CreatedAt = at
+		tk.task.EstimatedAt = at
+		return tk
+	}
+	done := estimated(4*time.Hour, mon)
+	done.task.IsDone = true
+	done.task.ActualUpdatedAt = mon.AddDate(0, 0, 2)
+	done.task.DoneAt = mon.AddDate(0, 0, 2)
+	old := estimated(8*time.Hour, mon.AddDate(0, 0, -4)) // estimated before the burndown
+	deleted := estimated(2*time.Hour, mon.AddDate(0, 0, 1))
+	deleted.task.IsDeleted = true
+	deleted.task.DeletedAt = mon.AddDate(0, 0, 7)
+	now := mon.AddDate(0, 0, 8).Add(2 * time.Hour) // Tuesday of the next week, noon
+	added := estimated(time.Hour, now.Add(-time.Hour))
+	unestimated := NewTask()
+	unestimated.task.CreatedAt = mon
+	ts := tasks{done, old, deleted, added, unestimated}
+
+	b := NewBurndown(wt, ts, mon, now)
+	assert.Equal(t, 9, len(b.Days))
+	assert.Equal(t, worktimes.StartOfDay(mon), b.Days[0].Date)
+	remaining := []time.Duration{12, 14, 10, 10, 10, 10, 10, 8, 9}
+	for i, d := range b.Days {
+		assert.Equal(t, remaining[i]*time.Hour, d.Remaining, "remaining on day %d", i)
+	}
+	assert.Equal(t, time.Duration(0), b.Days[1].Completed)
+	assert.Equal(t, 4*time.Hour, b.Days[8].Completed, "deleted task wasn't completed")
+	assert.Equal(t, 9*time.Hour, b.Remaining())
+
+	assert.Equal(t, 2, len(b.Weeks))
+	assert.Equal(t, 6*time.Hour, b.Weeks[0].Added)
+	assert.Equal(t, 4*time.Hour, b.Weeks[0].Completed)
+	assert.Equal(t, time.Hour, b.Weeks[1].Added)
+	assert.Equal(t, time.Duration(0), b.Weeks[1].Completed)
+
+	assert.Equal(t, "9.0h remaining estimated work", b.RenderProjected())
+	var dates [100]time.Time
+	for i := range dates {
+		dates[i] = now.AddDate(0, 0, i)
+	}
+	b.Project(dates)
+	assert.Equal(t, now.AddDate(0, 0, 9), b.Projected.Low)
+	assert.Equal(t, now.AddDate(0, 0, 49), b.Projected.Median)
+	assert.Equal(t, now.AddDate(0, 0, 89), b.Projected.High)
+
+	var buf bytes.Buffer
+	assert.NoError(t, b.WriteTable(&buf))
+	assert.Contains(t, buf.String(), "2018-01-01  6.0h   4.0h       10.0h")
+	assert.Contains(t, buf.String(), "2018-01-08  1.0h   0.0h       9.0h")
+	assert.Contains(t, buf.String(), "projected done Jan 18 (10%), Feb 27 (50%), Apr 8 (90%)")
+
+	b = NewBurndown(wt, ts.HasTag("nothing"), mon, now)
+	assert.Equal(t, "no remaining estimated work", b.RenderProjected())
+}
+
+func TestRemainingDeliverySchedule(t *testing.T) {
+	wt := worktimes.GetAnonymousWorkTimes()
+	// Monday 2018-01-01, 10am
+	mon := time.Date(2018, 1, 1, 10, 0, 0, 0, time.Local)
+	halfDone := getStartedTask()
+	halfDone.task.Estimated = 8 * time.Hour
+	halfDone.task.Sessions = []Session{newSession(mon.Add(-4*time.Hour), mon, 4*time.Hour, 1, SessionSourceManual)}
+	overrun := getStartedTask()
+	overrun.task.Estimated = time.Hour
+	overrun.task.Sessions = []Session{newSession(mon.Add(-2*time.Hour), mon, 2*time.Hour, 1, SessionSourceManual)}
+	ts := tasks{halfDone, overrun}
+	rs := []float64{1} // every estimate is perfect
+
+	dates := RemainingDeliverySchedule(wt, mon, rs, ts)
+	assert.Equal(t, wt.TimeAfter(mon, 4*time.Hour), dates[49], "only work left in started tasks is scheduled")
+	assert.Equal(t, dates[0], dates[99])
+	assert.Equal(t, wt.TimeAfter(mon, 9*time.Hour), DeliverySchedule(wt, mon, rs, ts)[49], "schedule of full estimates")
+}
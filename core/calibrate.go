@@ -0,0 +1,73 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// minCalibrationRatios is the min number of historical accuracy ratios from
+// which a calibrated estimate is suggested, see Calibrate().
+const minCalibrationRatios = 5
+
+// Calibration is the likely actual time of an estimate, per the accuracy of
+// historical estimates of similar size. See Calibrate().
+type Calibration struct {
+	Estimate time.Duration // estimate which was calibrated
+	Median   time.Duration // median likely actual time
+	P80      time.Duration // 80% of likely actual times are at most this
+	Count    int           // number of historical estimates used
+	MinSize  time.Duration // smallest historical estimate used
+	MaxSize  time.Duration // largest historical estimate used
+}
+
+// Calibrate returns the likely actual time of passed estimate, drawn from
+// the accuracy of historical estimates of similar size: within 2x of passed
+// estimate, or within 4x if there are too few of those, or else all
+// historical estimates. Returns false if there are too few historical
+// estimates to calibrate.
+func (ars AccuracyRatios) Calibrate(estimate time.Duration) (Calibration, bool) {
+	if estimate <= 0 {
+		return Calibration{}, false
+	}
+	var similar AccuracyRatios
+	for _, factor := range []time.Duration{2, 4, 0} {
+		similar = filterAccuracyRatios(ars, func(ar *AccuracyRatio) bool {
+			return factor == 0 || // all historical estimates
+				ar.duration*factor >= estimate && ar.duration <= estimate*factor
+		})
+		if len(similar) >= minCalibrationRatios {
+			break
+		}
+	}
+	if len(similar) < minCalibrationRatios {
+		return Calibration{}, false
+	}
+	c := Calibration{Estimate: estimate, Count: len(similar), MinSize: similar[0].duration, MaxSize: similar[0].duration}
+	actuals := make([]float64, len(similar))
+	for i, ar := range similar {
+		// ratio is estimated / actual, so this is the actual time of passed
+		// estimate if it's as accurate as this historical estimate.
+		actuals[i] = float64(estimate) / ar.ratio
+		if ar.duration < c.MinSize {
+			c.MinSize = ar.duration
+		}
+		if ar.duration > c.MaxSize {
+			c.MaxSize = ar.duration
+		}
+	}
+	sort.Float64s(actuals)
+	c.Median = time.Duration(median(actuals))
+	c.P80 = time.Duration(percentile(actuals, 0.8))
+	return c, true
+}
+
+// Render returns a user-suitable one line summary of this calibration.
+func (c Calibration) Render() string {
+	sizes := fmt.Sprintf("%.1fh-%.1fh", c.MinSize.Hours(), c.MaxSize.Hours())
+	if c.MinSize == c.MaxSize {
+		sizes = fmt.Sprintf("%.1fh", c.MinSize.Hours())
+	}
+	return fmt.Sprintf("calibrated: %.1fh likely takes %.1fh, or up to %.1fh (80%%), per %d done tasks estimated %s; estimate unchanged",
+		c.Estimate.Hours(), c.Median.Hours(), c.P80.Hours(), c.Count, sizes)
+}
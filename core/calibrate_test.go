@@ -0,0 +1,38 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalibrate(t *testing.T) {
+	ar := func(estimate time.Duration, ratio float64) AccuracyRatio {
+		return AccuracyRatio{duration: estimate, ratio: ratio}
+	}
+	ars := AccuracyRatios{
+		// small tasks are accurate
+		ar(30*time.Minute, 1), ar(30*time.Minute, 1), ar(30*time.Minute, 1), ar(30*time.Minute, 1), ar(30*time.Minute, 1),
+		// 4h tasks take longer
+		ar(3*time.Hour, 0.5), ar(4*time.Hour, 0.8), ar(4*time.Hour, 0.5), ar(5*time.Hour, 0.4), ar(8*time.Hour, 0.25),
+	}
+
+	c, ok := ars.Calibrate(4 * time.Hour)
+	assert.True(t, ok)
+	assert.Equal(t, 5, c.Count, "only size-similar tasks")
+	assert.Equal(t, 3*time.Hour, c.MinSize)
+	assert.Equal(t, 8*time.Hour, c.MaxSize)
+	assert.Equal(t, 8*time.Hour, c.Median)
+	assert.Equal(t, 11*time.Hour+12*time.Minute, c.P80, "interpolated between 10h and 16h")
+	assert.Equal(t, "calibrated: 4.0h likely takes 8.0h, or up to 11.2h (80%), per 5 done tasks estimated 3.0h-8.0h; estimate unchanged", c.Render())
+
+	c, ok = ars.Calibrate(40 * time.Hour)
+	assert.True(t, ok)
+	assert.Equal(t, 10, c.Count, "too few size-similar tasks, so all tasks")
+
+	_, ok = ars[:4].Calibrate(time.Hour)
+	assert.False(t, ok, "too few tasks")
+	_, ok = ars.Calibrate(0)
+	assert.False(t, ok, "unestimated")
+}
@@ -2,12 +2,17 @@ package core
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/gizak/termui"
 	"github.com/pkg/browser"
+	"github.com/ryanberckmans/est/core/worktimes"
 	chart "github.com/wcharczuk/go-chart"
 	drawing "github.com/wcharczuk/go-chart/drawing"
 )
@@ -15,12 +20,6 @@ import (
 // PredictedDeliveryDateChart renders a full-terminal chart, for predicted delivery
 // dates of passed tasks, until user presses 'Q' to quit.
 func PredictedDeliveryDateChart(predictedDaysInFuture []float64, ts tasks, pct []string) {
-	err := termui.Init()
-	if err != nil {
-		panic(err)
-	}
-	defer termui.Close()
-
 	lc0 := termui.NewLineChart()
 	lc0.BorderLabel = "Predicted delivery date for unstarted, estimated tasks"
 	// lc0.Mode = "dot"
@@ -64,27 +63,20 @@ func PredictedDeliveryDateChart(predictedDaysInFuture []float64, ts tasks, pct [
 	deliveryDateList.PaddingLeft = 1
 	deliveryDateList.Height = len(dds) + deliveryDateList.PaddingTop
 
-	termui.Body.AddRows(
-		termui.NewRow(
-			termui.NewCol(2, 0, yAxisLabel),
-			termui.NewCol(8, 0, lc0),
-			termui.NewCol(2, 0, deliveryDateList),
-		),
-		termui.NewRow(termui.NewCol(12, 4, xAxisLabel)),
-		termui.NewRow(termui.NewCol(12, 0, taskList)),
-	)
-	termui.Body.Align()
-	termui.Render(termui.Body)
-	termui.Handle("/sys/kbd/q", func(termui.Event) {
-		termui.StopLoop()
-	})
-	termui.Handle("/sys/wnd/resize", func(e termui.Event) {
-		termui.Body.Width = termui.TermWidth()
-		termui.Body.Align()
-		termui.Clear()
-		termui.Render(termui.Body)
-	})
-	termui.Loop()
+	err := runTerminalUI(func() []*termui.Row {
+		return []*termui.Row{
+			termui.NewRow(
+				termui.NewCol(2, 0, yAxisLabel),
+				termui.NewCol(8, 0, lc0),
+				termui.NewCol(2, 0, deliveryDateList),
+			),
+			termui.NewRow(termui.NewCol(12, 4, xAxisLabel)),
+			termui.NewRow(termui.NewCol(12, 0, taskList)),
+		}
+	}, nil, nil)
+	if err != nil {
+		panic(err)
+	}
 }
 
 // AccuracyRatioChart shows how accuracy the user has estimated,
@@ -131,17 +123,13 @@ func makeAccuracyRatioChart(ars []AccuracyRatio, now time.Time) *chart.Chart {
 		dws[i] = w
 	}
 	dcFunc := func(xr, yr chart.Range, index int, x, y float64) drawing.Color {
-		ratio := y
-		if ratio < 1 {
-			ratio = 1.0 / ratio
-		}
 		var alpha uint8 = 128
-		switch {
-		case ratio < 1.2:
+		switch accuracyRatioBand(y) {
+		case 0:
 			return drawing.Color{R: 0, G: 255, B: 0, A: alpha}
-		case ratio < 1.4:
+		case 1:
 			return drawing.Color{R: 0, G: 0, B: 255, A: alpha}
-		case ratio < 2.0:
+		case 2:
 			return drawing.Color{R: 255, G: 140, B: 0, A: alpha} // orange
 		}
 		return drawing.Color{R: 255, G: 0, B: 0, A: alpha}
@@ -199,6 +187,53 @@ func makeAccuracyRatioChart(ars []AccuracyRatio, now time.Time) *chart.Chart {
 	return c
 }
 
+// accuracyRatioBand returns how accurate passed accuracy ratio is, from 0
+// (within 20% of a perfect estimate) to 3 (off by 2x or more). Bands are
+// shown as colors in accuracy ratio charts.
+func accuracyRatioBand(ratio float64) int {
+	switch {
+	case withinAccuracy(ratio, 0.2):
+		return 0
+	case withinAccuracy(ratio, 0.4):
+		return 1
+	case withinAccuracy(ratio, 1):
+		return 2
+	}
+	return 3
+}
+
+// WriteAccuracyRatioChart writes the chart shown by AccuracyRatioChart() to
+// passed file, as a PNG or SVG image per the file's extension.
+func WriteAccuracyRatioChart(ars []AccuracyRatio, now time.Time, fileName string) error {
+	if len(ars) < 1 {
+		return errors.New("no historical accuracy ratios")
+	}
+	return writeChart(makeAccuracyRatioChart(ars, now), fileName)
+}
+
+// writeChart writes passed chart to passed file, as a PNG or SVG image per
+// the file's extension.
+func writeChart(c *chart.Chart, fileName string) error {
+	var rp chart.RendererProvider
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".png":
+		rp = chart.PNG
+	case ".svg":
+		rp = chart.SVG
+	default:
+		return fmt.Errorf("chart file name must end in .png or .svg, got '%s'", fileName)
+	}
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	if err := c.Render(rp, f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 func openChartInBrowser(c *chart.Chart, chartName string) error {
 	f, err := ioutil.TempFile("", chartName)
 	if err != nil {
@@ -211,3 +246,548 @@ func openChartInBrowser(c *chart.Chart, chartName string) error {
 	}
 	return browser.OpenFile(f.Name())
 }
+
+// AccuracyRatioTerminalChart renders a full-terminal version of the chart
+// shown by AccuracyRatioChart(), with passed summary, until user presses 'Q'
+// to quit. Useful where there's no browser or image viewer, e.g. over SSH.
+func AccuracyRatioTerminalChart(ars []AccuracyRatio, now time.Time, summary string) error {
+	if len(ars) < 1 {
+		return errors.New("no historical accuracy ratios")
+	}
+	scatter := &accuracyRatioScatter{Block: *termui.NewBlock(), ars: ars, now: now}
+	scatter.BorderLabel = "Accuracy ratio (estimate / actual), log scale, by calendar days ago"
+	scatter.Height = 20
+
+	legend := termui.NewPar("● larger estimate  • smaller estimate  ─ perfect estimate\n" +
+		"Above (below) the line is faster (slower) than expected.\n\n" +
+		summary + "\nQ to quit")
+	legend.Border = false
+	legend.PaddingLeft = 1
+	legend.Height = strings.Count(legend.Text, "\n") + 1
+	legend.TextFgColor = termui.ColorWhite
+
+	return runTerminalUI(func() []*termui.Row {
+		return []*termui.Row{
+			termui.NewRow(termui.NewCol(12, 0, scatter)),
+			termui.NewRow(termui.NewCol(12, 0, legend)),
+		}
+	}, nil, nil)
+}
+
+// runTerminalUI renders the rows returned by passed layout, which is called
+// once the terminal is initialized, full-terminal until the user presses 'Q'
+// to quit or a passed handler calls termui.StopLoop(). Passed render, if not
+// nil, renders the rows, e.g. after updating them; passed handlers handle
+// other termui events by path, e.g. "/timer/1s".
+func runTerminalUI(layout func() []*termui.Row, render func(), handlers map[string]func(termui.Event)) error {
+	if err := termui.Init(); err != nil {
+		return err
+	}
+	defer termui.Close()
+	if render == nil {
+		render = func() {
+			termui.Render(termui.Body)
+		}
+	}
+
+	termui.Body.AddRows(layout()...)
+	termui.Body.Align()
+	render()
+	termui.Handle("/sys/kbd/q", func(termui.Event) {
+		termui.StopLoop()
+	})
+	termui.Handle("/sys/wnd/resize", func(e termui.Event) {
+		termui.Body.Width = termui.TermWidth()
+		termui.Body.Align()
+		termui.Clear()
+		render()
+	})
+	for path, h := range handlers {
+		termui.Handle(path, h)
+	}
+	termui.Loop()
+	return nil
+}
+
+// setCell sets the cell of passed buffer at passed position to passed rune.
+func setCell(buf termui.Buffer, x, y int, ch rune, fg termui.Attribute) {
+	buf.Set(x, y, termui.Cell{Ch: ch, Fg: fg, Bg: termui.ColorDefault})
+}
+
+// setText sets the cells of passed buffer from passed position rightwards to
+// passed text, one rune per cell.
+func setText(buf termui.Buffer, x, y int, t string, fg termui.Attribute) {
+	for _, ch := range t {
+		setCell(buf, x, y, ch, fg)
+		x++
+	}
+}
+
+// accuracyRatioScatter is a termui widget which plots accuracy ratios by
+// calendar days ago, oldest on the left, colored like AccuracyRatioChart().
+// Ratios are on a log scale, so that an estimate which was 2x too high is as
+// far from the line of perfect estimates as one which was 2x too low.
+type accuracyRatioScatter struct {
+	termui.Block
+	ars []AccuracyRatio
+	now time.Time
+}
+
+// accuracyRatioScatterMaxLog2 bounds the y axis of accuracyRatioScatter to
+// ratios between 1/8 and 8. Ratios outside of that are shown at the bounds.
+const accuracyRatioScatterMaxLog2 = 3
+
+// Buffer implements termui.Bufferer.
+func (s *accuracyRatioScatter) Buffer() termui.Buffer {
+	buf := s.Block.Buffer()
+	r := s.InnerBounds()
+	const labelWidth = 6
+	left, bottom := r.Min.X+labelWidth, r.Max.Y-2 // last row is x axis labels
+	w, h := r.Max.X-left, bottom-r.Min.Y+1
+	if w < 2 || h < 2 {
+		return buf
+	}
+	row := func(ratio float64) int {
+		l := math.Max(-accuracyRatioScatterMaxLog2, math.Min(accuracyRatioScatterMaxLog2, math.Log2(ratio)))
+		return r.Min.Y + int(math.Round((accuracyRatioScatterMaxLog2-l)/(2*accuracyRatioScatterMaxLog2)*float64(h-1)))
+	}
+	maxDaysAgo := 0.0
+	for _, ar := range s.ars {
+		maxDaysAgo = math.Max(maxDaysAgo, math.Floor(s.now.Sub(ar.time).Hours()/24.0))
+	}
+	col := func(daysAgo float64) int {
+		if maxDaysAgo == 0 {
+			return left + w - 1
+		}
+		return left + int(math.Round((maxDaysAgo-daysAgo)/maxDaysAgo*float64(w-1)))
+	}
+
+	for _, ratio := range []float64{8, 4, 2, 1, 0.5, 0.25, 0.125} {
+		setText(buf, r.Min.X, row(ratio), fmt.Sprintf("%5g", ratio), termui.ColorWhite)
+	}
+	for x := left; x < left+w; x++ {
+		setCell(buf, x, row(1), '─', termui.ColorGreen)
+	}
+	setText(buf, left, bottom+1, fmt.Sprintf("%.0f days ago", maxDaysAgo), termui.ColorWhite)
+	setText(buf, left+w-5, bottom+1, "today", termui.ColorWhite)
+
+	colors := []termui.Attribute{termui.ColorGreen, termui.ColorBlue, termui.ColorYellow, termui.ColorRed}
+	for _, ar := range s.ars {
+		daysAgo := math.Max(0, math.Floor(s.now.Sub(ar.time).Hours()/24.0))
+		ch := '•'
+		if ar.duration.Hours() > 1 {
+			ch = '●'
+		}
+		setCell(buf, col(daysAgo), row(ar.ratio), ch, colors[accuracyRatioBand(ar.ratio)]|termui.AttrBold)
+	}
+	return buf
+}
+
+// WriteBurndownChart writes a chart of passed burndown to passed file, as a
+// PNG or SVG image per the file's extension.
+func WriteBurndownChart(b Burndown, fileName string) error {
+	if len(b.Days) < 2 {
+		return errors.New("burndown chart needs at least two days, see --since")
+	}
+	return writeChart(makeBurndownChart(b), fileName)
+}
+
+func makeBurndownChart(b Burndown) *chart.Chart {
+	// X axis: date
+	// Y axis: estimated hours remaining, and completed since start (burn-up)
+	// Secondary Y axis: estimated hours completed per week
+	xs := make([]time.Time, len(b.Days))
+	remaining := make([]float64, len(b.Days))
+	completed := make([]float64, len(b.Days))
+	maxHours := 1.0
+	for i, d := range b.Days {
+		xs[i] = d.Date
+		remaining[i] = d.Remaining.Hours()
+		completed[i] = d.Completed.Hours()
+		maxHours = math.Max(maxHours, math.Max(remaining[i], completed[i]))
+	}
+	weeks := make([]time.Time, len(b.Weeks))
+	weekly := make([]float64, len(b.Weeks))
+	maxWeekly := 1.0
+	for i, w := range b.Weeks {
+		weeks[i] = w.Start
+		weekly[i] = w.Completed.Hours()
+		maxWeekly = math.Max(maxWeekly, weekly[i])
+	}
+	series := []chart.Series{
+		chart.TimeSeries{
+			Name: "Remaining estimated hours",
+			Style: chart.Style{
+				Show:        true,
+				StrokeColor: drawing.ColorBlue,
+				FillColor:   drawing.ColorBlue.WithAlpha(64),
+			},
+			XValues: xs,
+			YValues: remaining,
+		},
+		chart.TimeSeries{
+			Name: "Completed estimated hours (burn-up)",
+			Style: chart.Style{
+				Show:        true,
+				StrokeColor: drawing.ColorGreen,
+			},
+			XValues: xs,
+			YValues: completed,
+		},
+		chart.TimeSeries{
+			Name: "Completed estimated hours per week (right axis)",
+			Style: chart.Style{
+				Show:        true,
+				StrokeWidth: chart.Disabled,
+				DotWidth:    5,
+				DotColor:    drawing.Color{R: 0, G: 128, B: 0, A: 255},
+			},
+			YAxis:   chart.YAxisSecondary,
+			XValues: weeks,
+			YValues: weekly,
+		},
+	}
+	if p := b.Projected; p != nil && b.Remaining() > 0 {
+		today := b.Days[len(b.Days)-1].Date
+		for _, proj := range []struct {
+			name  string
+			date  time.Time
+			color drawing.Color
+		}{
+			{"Projected done, 10%", p.Low, drawing.ColorBlack.WithAlpha(96)},
+			{"Projected done, 50%", p.Median, drawing.Color{R: 255, G: 140, B: 0, A: 255}},
+			{"Projected done, 90%", p.High, drawing.ColorBlack.WithAlpha(96)},
+		} {
+			series = append(series, chart.TimeSeries{
+				Name: proj.name,
+				Style: chart.Style{
+					Show:            true,
+					StrokeColor:     proj.color,
+					StrokeDashArray: []float64{5, 5},
+				},
+				XValues: []time.Time{today, proj.date},
+				YValues: []float64{b.Remaining().Hours(), 0},
+			})
+		}
+	}
+	c := &chart.Chart{
+		XAxis: chart.XAxis{
+			Style:          chart.StyleShow(),
+			ValueFormatter: chart.TimeValueFormatterWithFormat("Jan 2"),
+		},
+		YAxis: chart.YAxis{
+			Name:      "Estimated Hours",
+			NameStyle: chart.StyleShow(),
+			Style:     chart.StyleShow(),
+			Range:     &chart.ContinuousRange{Min: 0, Max: maxHours},
+		},
+		YAxisSecondary: chart.YAxis{
+			Style: chart.StyleShow(),
+			Range: &chart.ContinuousRange{Min: 0, Max: maxWeekly},
+		},
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top:    30,
+				Left:   20,
+				Right:  20,
+				Bottom: 20,
+			},
+		},
+		Series: series,
+	}
+	c.Elements = []chart.Renderable{
+		chart.LegendThin(c),
+	}
+	return c
+}
+
+// BurndownTerminalChart renders a full-terminal chart of passed burndown,
+// with passed summary, until user presses 'Q' to quit.
+func BurndownTerminalChart(b Burndown, summary string) error {
+	if len(b.Days) < 1 {
+		return errors.New("no days in burndown")
+	}
+	return runTerminalUI(func() []*termui.Row {
+		return burndownTerminalChartRows(b, summary)
+	}, nil, nil)
+}
+
+// burndownTerminalChartRows returns the rows of BurndownTerminalChart(). The
+// terminal must be initialized, so that the weekly bars fit its width.
+func burndownTerminalChartRows(b Burndown, summary string) []*termui.Row {
+	plot := &burndownPlot{Block: *termui.NewBlock(), b: b}
+	plot.BorderLabel = "Remaining estimated hours, and projected completion"
+	plot.Height = 18
+
+	weekly := termui.NewBarChart()
+	weekly.BorderLabel = "Completed estimated hours per week"
+	weekly.Height = 10
+	weekly.BarWidth = 6
+	weekly.BarColor = termui.ColorGreen
+	weekly.NumColor = termui.ColorBlack
+	for _, w := range b.Weeks {
+		weekly.Data = append(weekly.Data, int(math.Round(w.Completed.Hours())))
+		weekly.DataLabels = append(weekly.DataLabels, w.Start.Format("Jan 2"))
+	}
+	if n := (termui.TermWidth() - 2) / (weekly.BarWidth + weekly.BarGap); n > 0 && len(weekly.Data) > n {
+		// show the most recent weeks which fit
+		weekly.Data = weekly.Data[len(weekly.Data)-n:]
+		weekly.DataLabels = weekly.DataLabels[len(weekly.DataLabels)-n:]
+	}
+
+	legend := termui.NewPar("█ remaining  • projected done, 50%  · 10% and 90%\n\n" + summary + "\nQ to quit")
+	legend.Border = false
+	legend.PaddingLeft = 1
+	legend.Height = strings.Count(legend.Text, "\n") + 1
+	legend.TextFgColor = termui.ColorWhite
+
+	return []*termui.Row{
+		termui.NewRow(termui.NewCol(12, 0, plot)),
+		termui.NewRow(termui.NewCol(12, 0, weekly)),
+		termui.NewRow(termui.NewCol(12, 0, legend)),
+	}
+}
+
+// burndownPlot is a termui widget which plots the remaining work of a
+// burndown by day as bars, oldest on the left, followed by lines from today's
+// remaining work to the projected completion dates.
+type burndownPlot struct {
+	termui.Block
+	b Burndown
+}
+
+// Buffer implements termui.Bufferer.
+func (p *burndownPlot) Buffer() termui.Buffer {
+	buf := p.Block.Buffer()
+	r := p.InnerBounds()
+	const labelWidth = 7
+	left, bottom := r.Min.X+labelWidth, r.Max.Y-2 // last row is x axis labels
+	w, h := r.Max.X-left, bottom-r.Min.Y+1
+	if w < 2 || h < 2 || len(p.b.Days) < 1 {
+		return buf
+	}
+
+	first, today := p.b.Days[0].Date, p.b.Days[len(p.b.Days)-1].Date
+	last := today
+	projected := p.b.Projected != nil && p.b.Remaining() > 0
+	if projected && p.b.Projected.High.After(last) {
+		last = worktimes.StartOfDay(p.b.Projected.High)
+	}
+	maxHours := 1.0
+	for _, d := range p.b.Days {
+		maxHours = math.Max(maxHours, d.Remaining.Hours())
+	}
+	span := last.Sub(first).Hours()
+	col := func(t time.Time) int {
+		if span <= 0 {
+			return left + w - 1
+		}
+		return left + int(math.Round(t.Sub(first).Hours()/span*float64(w-1)))
+	}
+	row := func(hours float64) int {
+		return bottom - int(math.Round(hours/maxHours*float64(h-1)))
+	}
+	colTime := func(x int) time.Time {
+		return first.Add(time.Duration(float64(x-left) / float64(w-1) * float64(last.Sub(first))))
+	}
+
+	setText(buf, r.Min.X, row(maxHours), fmt.Sprintf("%5.0fh", maxHours), termui.ColorWhite)
+	setText(buf, r.Min.X, row(maxHours/2), fmt.Sprintf("%5.0fh", maxHours/2), termui.ColorWhite)
+	setText(buf, r.Min.X, bottom, fmt.Sprintf("%5.0fh", 0.0), termui.ColorWhite)
+	setText(buf, left, bottom+1, first.Format("Jan 2"), termui.ColorWhite)
+	if lastLabel := last.Format("Jan 2"); w > 2*len(lastLabel)+1 {
+		setText(buf, left+w-len(lastLabel), bottom+1, lastLabel, termui.ColorWhite)
+	}
+
+	for x := left; x <= col(today); x++ {
+		remaining := p.b.remainingAt(colTime(x))
+		if remaining <= 0 {
+			continue
+		}
+		for y := row(remaining.Hours()); y <= bottom; y++ {
+			setCell(buf, x, y, '█', termui.ColorCyan)
+		}
+	}
+	if !projected {
+		return buf
+	}
+	remaining := p.b.Remaining().Hours()
+	line := func(done time.Time, ch rune, fg termui.Attribute) {
+		d := done.Sub(today)
+		for x := col(today) + 1; x < left+w; x++ {
+			t := colTime(x)
+			if d <= 0 || t.After(done) {
+				break
+			}
+			setCell(buf, x, row(remaining*(1-float64(t.Sub(today))/float64(d))), ch, fg)
+		}
+	}
+	line(p.b.Projected.Low, '·', termui.ColorWhite)
+	line(p.b.Projected.High, '·', termui.ColorWhite)
+	line(p.b.Projected.Median, '•', termui.ColorYellow|termui.AttrBold)
+	return buf
+}
+
+// WriteScheduleHistoryChart writes a chart of how passed schedule history's
+// predicted dates moved over time to passed file, as a PNG or SVG image per
+// the file's extension.
+func WriteScheduleHistoryChart(h ScheduleHistory, fileName string) error {
+	if len(h) < 2 {
+		return errors.New("schedule history chart needs at least two recorded schedules")
+	}
+	return writeChart(makeScheduleHistoryChart(h), fileName)
+}
+
+func makeScheduleHistoryChart(h ScheduleHistory) *chart.Chart {
+	// X axis: when the schedule was predicted
+	// Y axis: predicted date, as unix nanoseconds because go-chart supports only float data
+	xs := make([]time.Time, len(h))
+	p50s := make([]float64, len(h))
+	p80s := make([]float64, len(h))
+	p95s := make([]float64, len(h))
+	minDate, maxDate := math.MaxFloat64, 0.0
+	for i, s := range h {
+		xs[i] = s.At
+		p50s[i] = float64(s.P50.UnixNano())
+		p80s[i] = float64(s.P80.UnixNano())
+		p95s[i] = float64(s.P95.UnixNano())
+		minDate = math.Min(minDate, math.Min(p50s[i], math.Min(p80s[i], p95s[i])))
+		maxDate = math.Max(maxDate, math.Max(p50s[i], math.Max(p80s[i], p95s[i])))
+	}
+	day := float64(24 * time.Hour)
+	series := func(name string, ys []float64, color drawing.Color) chart.Series {
+		return chart.TimeSeries{
+			Name: name,
+			Style: chart.Style{
+				Show:        true,
+				StrokeColor: color,
+				DotWidth:    3,
+				DotColor:    color,
+			},
+			XValues: xs,
+			YValues: ys,
+		}
+	}
+	c := &chart.Chart{
+		XAxis: chart.XAxis{
+			Name:           "Predicted On",
+			NameStyle:      chart.StyleShow(),
+			Style:          chart.StyleShow(),
+			ValueFormatter: chart.TimeValueFormatterWithFormat("Jan 2"),
+		},
+		YAxis: chart.YAxis{
+			Name:           "Predicted Delivery Date",
+			NameStyle:      chart.StyleShow(),
+			Style:          chart.StyleShow(),
+			ValueFormatter: chart.TimeValueFormatterWithFormat("Jan 2"),
+			Range:          &chart.ContinuousRange{Min: minDate - day, Max: maxDate + day},
+		},
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top:    30,
+				Left:   20,
+				Right:  20,
+				Bottom: 20,
+			},
+		},
+		Series: []chart.Series{
+			series("50% likely", p50s, drawing.ColorGreen),
+			series("80% likely", p80s, drawing.Color{R: 255, G: 140, B: 0, A: 255}), // orange
+			series("95% likely", p95s, drawing.ColorRed),
+		},
+	}
+	c.Elements = []chart.Renderable{
+		chart.LegendThin(c),
+	}
+	return c
+}
+
+// ScheduleHistoryTerminalChart renders a full-terminal chart of how passed
+// schedule history's predicted dates moved over time, with passed summary,
+// until user presses 'Q' to quit.
+func ScheduleHistoryTerminalChart(h ScheduleHistory, summary string) error {
+	if len(h) < 1 {
+		return errors.New("no schedule history")
+	}
+	plot := &scheduleHistoryPlot{Block: *termui.NewBlock(), h: h}
+	plot.BorderLabel = "Predicted delivery date, by date predicted"
+	plot.Height = 20
+
+	legend := termui.NewPar("[•](fg-green) 50% likely  [•](fg-yellow) 80% likely  [•](fg-red) 95% likely\n\n" + summary + "\nQ to quit")
+	legend.Border = false
+	legend.PaddingLeft = 1
+	legend.Height = strings.Count(legend.Text, "\n") + 1
+	legend.TextFgColor = termui.ColorWhite
+
+	return runTerminalUI(func() []*termui.Row {
+		return []*termui.Row{
+			termui.NewRow(termui.NewCol(12, 0, plot)),
+			termui.NewRow(termui.NewCol(12, 0, legend)),
+		}
+	}, nil, nil)
+}
+
+// scheduleHistoryPlot is a termui widget which plots the 50%, 80% and 95%
+// predicted dates of a schedule history as steps, oldest prediction on the
+// left, so that a rising line is a slipping schedule.
+type scheduleHistoryPlot struct {
+	termui.Block
+	h ScheduleHistory
+}
+
+// Buffer implements termui.Bufferer.
+func (p *scheduleHistoryPlot) Buffer() termui.Buffer {
+	buf := p.Block.Buffer()
+	r := p.InnerBounds()
+	const labelWidth = 7
+	left, bottom := r.Min.X+labelWidth, r.Max.Y-2 // last row is x axis labels
+	w, h := r.Max.X-left, bottom-r.Min.Y+1
+	if w < 2 || h < 2 || len(p.h) < 1 {
+		return buf
+	}
+
+	first, last := p.h[0].At, p.h[len(p.h)-1].At
+	minDate, maxDate := p.h[0].P50, p.h[0].P95
+	for _, s := range p.h {
+		for _, t := range []time.Time{s.P50, s.P80, s.P95} {
+			if t.Before(minDate) {
+				minDate = t
+			}
+			if t.After(maxDate) {
+				maxDate = t
+			}
+		}
+	}
+	if !maxDate.After(minDate) {
+		maxDate = minDate.AddDate(0, 0, 1)
+	}
+	row := func(t time.Time) int {
+		return bottom - int(math.Round(float64(t.Sub(minDate))/float64(maxDate.Sub(minDate))*float64(h-1)))
+	}
+	colTime := func(x int) time.Time {
+		return first.Add(time.Duration(float64(x-left) / float64(w-1) * float64(last.Sub(first))))
+	}
+
+	mid := minDate.Add(maxDate.Sub(minDate) / 2)
+	for _, t := range []time.Time{maxDate, mid, minDate} {
+		setText(buf, r.Min.X, row(t), fmt.Sprintf("%6s", t.Format("Jan 2")), termui.ColorWhite)
+	}
+	setText(buf, left, bottom+1, first.Local().Format("Jan 2"), termui.ColorWhite)
+	if lastLabel := last.Local().Format("Jan 2"); w > 2*len(lastLabel)+1 {
+		setText(buf, left+w-len(lastLabel), bottom+1, lastLabel, termui.ColorWhite)
+	}
+
+	i := 0
+	for x := left; x < left+w; x++ {
+		// the most recent snapshot predicted at or before this column
+		t := colTime(x)
+		for i+1 < len(p.h) && !p.h[i+1].At.After(t) {
+			i++
+		}
+		s := p.h[i]
+		setCell(buf, x, row(s.P95), '•', termui.ColorRed)
+		setCell(buf, x, row(s.P80), '•', termui.ColorYellow)
+		setCell(buf, x, row(s.P50), '•', termui.ColorGreen|termui.AttrBold)
+	}
+	return buf
+}
@@ -0,0 +1,18 @@
+package core
+
+import (
+	"image"
+	"testing"
+
+	"github.com/gizak/termui"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetText(t *testing.T) {
+	buf := termui.NewBuffer()
+	setText(buf, 2, 1, "●─a", termui.ColorWhite)
+	assert.Equal(t, 3, len(buf.CellMap), "one cell per rune")
+	assert.Equal(t, '●', buf.CellMap[image.Pt(2, 1)].Ch)
+	assert.Equal(t, '─', buf.CellMap[image.Pt(3, 1)].Ch)
+	assert.Equal(t, 'a', buf.CellMap[image.Pt(4, 1)].Ch)
+}
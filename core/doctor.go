@@ -0,0 +1,196 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Problem is a violation of an invariant of an estfile. Invariants are
+// maintained by est, but may be violated by hand edits to an estfile or by
+// bugs. Some violated invariants cause est to panic.
+type Problem struct {
+	Task        *Task  // task which violates the invariant, nil if the problem isn't specific to one task
+	Explanation string // what is wrong and why it matters
+	Fix         string // description of a safe automatic fix, empty if there's none
+	fix         func() // applies Fix
+}
+
+// CanFix returns true iff this problem has a safe automatic fix.
+func (p Problem) CanFix() bool {
+	return p.fix != nil
+}
+
+// Check returns the problems in this EstFile, in the order in which they
+// should be fixed. See Problem.
+func (ef *EstFile) Check(now time.Time) []Problem {
+	var ps []Problem
+	ps = append(ps, ef.checkIDs()...)
+	for _, t := range ef.Tasks {
+		ps = append(ps, checkTask(t, now)...)
+	}
+	ps = append(ps, ef.checkFakeRatios()...)
+	return ps
+}
+
+// Fix applies the automatic fix for each passed problem, and returns the
+// problems which were fixed. Problems should be fixed in the order returned
+// by Check(), and Check() should be re-run after fixing, because fixing one
+// problem may resolve or reveal others.
+func (ef *EstFile) Fix(ps []Problem) []Problem {
+	var fixed []Problem
+	for _, p := range ps {
+		if p.CanFix() {
+			p.fix()
+			fixed = append(fixed, p)
+		}
+	}
+	return fixed
+}
+
+// RenderProblem returns a user-suitable rendering of the passed problem.
+func RenderProblem(p Problem) string {
+	var s string
+	if p.Task != nil {
+		s = fmt.Sprintf("task %s \"%s\": %s", p.Task.ID().String()[0:5], p.Task.Name(), p.Explanation)
+	} else {
+		s = p.Explanation
+	}
+	if p.CanFix() {
+		return s + "\n  fix: " + p.Fix
+	}
+	return s + "\n  no automatic fix, edit your estfile by hand"
+}
+
+func (ef *EstFile) checkIDs() []Problem {
+	var ps []Problem
+	seen := make(map[uuid.UUID]*Task, len(ef.Tasks))
+	for i := range ef.Tasks {
+		t := ef.Tasks[i]
+		if t.ID() == uuid.Nil {
+			ps = append(ps, Problem{
+				Task:        t,
+				Explanation: "task has no ID, so it can't be found by ID prefix",
+				Fix:         "give the task a new ID",
+				fix:         func() { t.task.ID = uuid.New() },
+			})
+			continue
+		}
+		other, ok := seen[t.ID()]
+		if !ok {
+			seen[t.ID()] = t
+			continue
+		}
+		if encodeTask(other.task) == encodeTask(t.task) {
+			ps = append(ps, Problem{
+				Task:        t,
+				Explanation: "task is an exact duplicate of another task, so its evidence would be counted twice",
+				Fix:         "remove the duplicate",
+				fix: func() {
+					for j := range ef.Tasks {
+						if ef.Tasks[j] == t {
+							ef.Tasks = append(ef.Tasks[:j], ef.Tasks[j+1:]...)
+							return
+						}
+					}
+				},
+			})
+			continue
+		}
+		ps = append(ps, Problem{
+			Task:        t,
+			Explanation: "task has the same ID as another task \"" + other.Name() + "\", so commands may change the wrong task",
+			Fix:         "give the task a new ID",
+			fix:         func() { t.task.ID = uuid.New() },
+		})
+	}
+	return ps
+}
+
+func checkTask(t *Task, now time.Time) []Problem {
+	var ps []Problem
+	p := func(explanation, fix string, fn func()) {
+		ps = append(ps, Problem{Task: t, Explanation: explanation, Fix: fix, fix: fn})
+	}
+	if t.task.Name == "" {
+		p("task name is empty", "rename the task to \"unnamed task\"", func() { t.task.Name = "unnamed task" })
+	} else if len(t.task.Name) > taskNameMaxLen {
+		p(fmt.Sprintf("task name is longer than %d characters", taskNameMaxLen), "truncate the task name", func() { t.task.Name = truncateName(t.task.Name) })
+	}
+	if t.task.Estimated < 0 {
+		p("estimate is negative", "", nil)
+	}
+	if t.task.Actual != 0 {
+		p("task has legacy actual time which isn't counted in its sessions", "move legacy actual time into a session", func() { t.task.addLegacySession(SessionSourceMigrated) })
+	}
+	for i := range t.task.Sessions {
+		s := &t.task.Sessions[i]
+		if s.Duration < 0 {
+			p("session "+s.ID.String()[0:5]+" has negative duration, which would corrupt accuracy ratios", "set session duration to zero", func() { s.Duration = 0 })
+		}
+		if s.End.Before(s.Start) {
+			p("session "+s.ID.String()[0:5]+" ends before it starts", "set session end to its start", func() { s.End = s.Start })
+		}
+	}
+	if t.task.ActualUpdatedAt.After(now) {
+		p("time was last tracked in the future ("+t.task.ActualUpdatedAt.Format(time.RFC3339)+"), so auto time tracking is suspended until then", "set time last tracked to now", func() { t.task.ActualUpdatedAt = now })
+	}
+	if t.IsNeverStarted() {
+		if t.Actual() > 0 {
+			p("task was never started but has actual time", "mark the task as paused (or done, if it was marked done) as of its most recent activity", func() {
+				t.task.ActualUpdatedAt = latestTime(t.task.CreatedAt, t.task.EstimatedAt, t.task.StartedAt, t.task.PausedAt, t.task.DoneAt)
+				t.task.IsPaused = !t.task.IsDone
+			})
+		} else if t.task.IsPaused || t.task.IsDone {
+			p("task was never started but is marked paused or done", "clear the paused and done flags", func() {
+				t.task.IsPaused = false
+				t.task.IsDone = false
+			})
+		}
+		return ps
+	}
+	if t.task.IsDone && t.task.IsPaused {
+		p("task is marked both done and paused", "clear the paused flag", func() { t.task.IsPaused = false })
+	}
+	if t.IsStarted() && t.IsDeleted() {
+		p("task is started and deleted, but started tasks are never deleted; est would panic on this task", "pause the task as of the time it was last tracked", func() {
+			t.task.IsPaused = true
+			t.task.PausedAt = t.task.ActualUpdatedAt
+		})
+	}
+	if t.IsStarted() && !t.IsEstimated() {
+		p("task is started but unestimated, but only estimated tasks may be started", "", nil)
+	}
+	if t.IsDone() && t.task.DoneAt.IsZero() {
+		p("task is done but has no done time, so it's missing from history", "set done time to the time it was last tracked", func() { t.task.DoneAt = t.task.ActualUpdatedAt })
+	}
+	if t.task.StartedAt.IsZero() {
+		p("task was started but has no start time", "set start time to the time it was last tracked", func() { t.task.StartedAt = t.task.ActualUpdatedAt })
+	}
+	return ps
+}
+
+func (ef *EstFile) checkFakeRatios() []Problem {
+	for _, r := range ef.FakeHistoricalEstimateAccuracyRatios {
+		if r <= 0 || math.IsNaN(r) || math.IsInf(r, 0) {
+			return []Problem{{
+				Explanation: "fake historical accuracy ratios include a ratio which isn't a positive number, which would corrupt 'est schedule'",
+				Fix:         "regenerate fake historical accuracy ratios",
+				fix:         func() { ef.FakeHistoricalEstimateAccuracyRatios = makeFakeHistoricalEstimateAccuracyRatios() },
+			}}
+		}
+	}
+	return nil
+}
+
+func latestTime(ts ...time.Time) time.Time {
+	var latest time.Time
+	for _, t := range ts {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
@@ -0,0 +1,48 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAndFix(t *testing.T) {
+	now := time.Now().Add(time.Minute)
+	started := getStartedTask()
+	started.task.IsDeleted = true
+	negative := getPausedTask()
+	negative.task.Sessions = []Session{newSession(now, now, -time.Hour, 1, SessionSourceManual)}
+	future := getPausedTask()
+	future.task.ActualUpdatedAt = now.Add(time.Hour)
+	unestimated := getStartedTask()
+	unestimated.task.Estimated = 0
+	ok := getStartedTask()
+	for i, tk := range []*Task{started, negative, future, unestimated, ok} {
+		_ = tk.SetName(fmt.Sprintf("task %d", i))
+		tk.task.StartedAt = tk.task.ActualUpdatedAt
+	}
+	ok.task.Name = "x" + strings.Repeat("é", taskNameMaxLen)
+	duplicate := &Task{task: future.task}
+	ef := EstFile{
+		Tasks:                                tasks{started, negative, future, duplicate, unestimated, ok},
+		FakeHistoricalEstimateAccuracyRatios: []float64{0.8, 0},
+	}
+
+	ps := ef.Check(now)
+	assert.Equal(t, 8, len(ps), "deleted started, negative session, future (x2), duplicate, unestimated started, long name, bad fake ratio")
+	ef.Fix(ps)
+	ps = ef.Check(now)
+	assert.Equal(t, 1, len(ps), "only unestimated started task can't be fixed")
+	assert.Equal(t, unestimated, ps[0].Task)
+	assert.False(t, ps[0].CanFix())
+
+	assert.Equal(t, 5, len(ef.Tasks), "duplicate removed")
+	assert.True(t, started.IsPaused())
+	assert.Equal(t, time.Duration(0), negative.Actual())
+	assert.Equal(t, now, future.task.ActualUpdatedAt)
+	assert.Equal(t, "x"+strings.Repeat("é", taskNameMaxLen/2-1), ok.Name(), "long name truncated on a rune boundary")
+	assert.Equal(t, 20, len(ef.FakeHistoricalEstimateAccuracyRatios), "fake ratios regenerated")
+}
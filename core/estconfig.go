@@ -1,43 +1,337 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/ryanberckmans/est/core/worktimes"
 	"github.com/spf13/viper"
 )
 
 const estConfigMode os.FileMode = 0600
+const estDirMode os.FileMode = 0700
 
-const estConfigDefaultContents string = `# Your estfile.toml stores your tasks and estimates. Some users may want to change this to a location with automatic backup, such as Dropbox or Google Drive.
-# At this time the only supported env var is "$HOME", other env vars will not work.
-estfile = "$HOME/.estfile.toml"
+const estConfigDefaultContents string = `# Your estfile stores your tasks and estimates. Some users may want to change this to a location with automatic backup, such as Dropbox or Google Drive.
+# Any environment variable, e.g. "$HOME" or "$XDG_DATA_HOME", and a leading "~" are expanded.
+# If unset, the estfile is "$HOME/.estfile.toml" if that file exists, otherwise "$XDG_DATA_HOME/est/estfile.toml".
+# estfile = "~/Dropbox/estfile.toml"
+
+# Storage is how the estfile is stored, see 'est help convert'. "toml" (the
+# default) is one file which is easy to read and edit by hand. "jsonl" is an
+# append-only log of changes, which saves faster for large estfiles and keeps
+# a history of recent changes. Use 'est convert' to change storage. An estfile
+# ending in .toml or .jsonl always uses that storage.
+# storage = "toml"
+
+# Working hours are used for auto time tracking and predicted schedules. Time on
+# tasks outside of working hours doesn't count towards auto time tracking. When
+# customizing working hours, tend to understate them, so that time worked outside
+# of working hours is a bonus and, if not worked, not a penalty. The default is:
+# workdays = ["monday", "tuesday", "wednesday", "thursday", "friday"]
+# workhours = ["9:30am", "12:00pm", "12:30pm", "5:30pm"] # 9:30am-noon, 30 minutes for lunch, then 12:30pm-5:30pm
+
+# Timezone is the IANA timezone of your working hours, e.g. "America/New_York".
+# If set, est uses this timezone for working hours, auto time tracking, and days
+# in 'est yesterday', reports, stats and burndowns, so that these don't shift
+# when you travel or your machine changes timezone. Times are still shown in the
+# machine's local timezone. If unset, est uses the machine's local timezone.
+# timezone = "America/New_York"
+
+# A started task is stale, i.e. probably forgotten, if its actual time exceeds
+# stalemultiple times its estimate, or if it has had no activity for stalehours
+# working hours. est warns about stale tasks after each command, see 'est help
+# stale'. To disable either check, set it to a negative number. The default is:
+# stalemultiple = 3.0
+# stalehours = 16.0
+
+# est-prompt can record shell activity, so that started tasks stop counting time
+# while you're away. If there's no shell activity for more than idleminutes
+# working minutes while tasks are started, the next est command either asks
+# whether to pause those tasks as of the start of the gap ("ask"), or pauses them
+# ("pause"). See 'est help idle'. The default is:
+# idle = "off"
+# idleminutes = 60.0
+
+# Profiles keep separate estfiles, e.g. for work and side projects. Select a
+# profile with 'est --profile <name>' or by setting $EST_PROFILE. A profile's
+# settings override the settings above.
+# [profiles.side]
+# estfile = "~/Dropbox/side-project-estfile.toml"
+# storage = "jsonl"
+# workdays = ["saturday", "sunday"]
+# workhours = ["10:00am", "2:00pm"]
+# timezone = "Europe/London"
 `
 
-const estConfigDefaultFileNameNoSuffix string = ".estconfig"
-const estConfigDefaultFileSuffix string = ".toml"
-const estConfigDefaultFileName string = estConfigDefaultFileNameNoSuffix + estConfigDefaultFileSuffix
+// Legacy locations, used iff they already exist. New users get XDG locations.
+const estConfigLegacyFileName string = "$HOME/.estconfig.toml"
+const estFileLegacyFileName string = "$HOME/.estfile.toml"
+
+// XDG Base Directory locations, see https://specifications.freedesktop.org/basedir-spec/.
+const estConfigXDGFileName string = "$XDG_CONFIG_HOME/est/estconfig.toml"
+const estFileXDGFileName string = "$XDG_DATA_HOME/est/estfile.toml"
+
+// Environment variables which override the estconfig, see ConfigOverrides.
+const estConfigEnv string = "EST_CONFIG"
+const estFileEnv string = "EST_FILE"
+const estProfileEnv string = "EST_PROFILE"
+
+var defaultWorkdays = []string{"monday", "tuesday", "wednesday", "thursday", "friday"}
+
+var defaultWorkhours = []string{
+	// Work 9:30am-noon
+	"9:30am",
+	"12:00pm",
+	// 30 minutes for lunch, then work 12:30pm-5:30pm
+	"12:30pm",
+	"5:30pm",
+	// Time on tasks outside of these times will not count towards automatic time tracking. This doesn't mean no work occurs outside of these times, it just means the estimator isn't penalized (by additional auto time tracking duration) for not making progress during non-working hours. `est log` can also be used as an escape hatch, e.g. for a 3h project on a Saturday.
+}
 
 // EstConfig is the user preferences file for est.
-// $HOME/.estconfig is deserialized into this struct.
+// The estconfig file is deserialized into this struct.
 type EstConfig struct {
-	Estfile string // est file name
+	Estfile   string                      // est file name
+	Storage   string                      // how the estfile is stored, one of StorageKinds
+	Workdays  []string                    // days of the week which have working hours, e.g. "monday"
+	Workhours []string                    // working hours on each workday, see worktimes.New()
+	Timezone  string                      // IANA timezone of working hours, e.g. "America/New_York"; empty means the machine's local timezone
+	Profiles  map[string]EstConfigProfile // named profiles, which override the settings above
+
+	StaleMultiple float64 // a started task is stale if its actual exceeds this multiple of its estimate; zero means default, negative disables
+	StaleHours    float64 // a started task is stale if it has no activity for this many working hours; zero means default, negative disables
+
+	Idle        string  // what to do with started tasks after shell inactivity, one of IdleOff, IdleAsk, IdlePause
+	IdleMinutes float64 // working minutes without shell activity after which started tasks are idle; zero means default
+
+	profile   string              // name of selected profile, if any
+	workTimes worktimes.WorkTimes // constructed from Workdays and Workhours
+}
+
+// EstConfigProfile is a named set of settings which override EstConfig.
+type EstConfigProfile struct {
+	Estfile   string
+	Storage   string
+	Workdays  []string
+	Workhours []string
+	Timezone  string
+}
+
+// ConfigOverrides are overrides of the estconfig for one invocation of est,
+// e.g. from command-line flags. Each override takes precedence over its
+// corresponding environment variable, which takes precedence over estconfig.
+type ConfigOverrides struct {
+	ConfigFile string // estconfig file name, overrides $EST_CONFIG
+	Estfile    string // estfile name, overrides $EST_FILE and the estfile in estconfig
+	Profile    string // estconfig profile name, overrides $EST_PROFILE
+}
+
+var configOverrides ConfigOverrides
+
+// SetConfigOverrides sets the passed overrides for all subsequent loads of
+// estconfig in this process, and forgets the estconfig and estfile already
+// loaded, see WithEstConfigAndFile().
+func SetConfigOverrides(o ConfigOverrides) {
+	configOverrides = o
+	loadedEstConfigAndFile = estConfigAndFile{}
+}
+
+// WorkTimes returns the working hours defined by this estconfig.
+func (ec *EstConfig) WorkTimes() worktimes.WorkTimes {
+	return ec.workTimes
+}
+
+// IdleThreshold returns the working time without shell activity after which
+// started tasks are idle, see EstFile.Heartbeat().
+func (ec *EstConfig) IdleThreshold() time.Duration {
+	return time.Duration(ec.IdleMinutes * float64(time.Minute))
+}
+
+// storage returns the storage of the estfile defined by this estconfig.
+func (ec *EstConfig) storage() (Storage, error) {
+	return NewStorage(ec.Storage, expandPath(ec.Estfile))
+}
+
+// ProfileName returns the name of the selected profile, or "" if none.
+func (ec *EstConfig) ProfileName() string {
+	return ec.profile
 }
 
 // getEstconfig returns the singleton estConfig for this process.
 // Creates a config file if none found.
 func getEstConfig() (EstConfig, error) {
-	if err := createFileWithDefaultContentsIfNotExists(os.Getenv("HOME")+"/"+estConfigDefaultFileName, estConfigMode, estConfigDefaultContents); err != nil {
-		return EstConfig{}, fmt.Errorf("couldn't find or create %s: %s", estConfigDefaultFileName, err)
+	estConfigFileName, isDefault := getEstConfigFileName()
+	if isDefault {
+		if err := createFileWithDefaultContentsIfNotExists(estConfigFileName, estConfigMode, estConfigDefaultContents); err != nil {
+			return EstConfig{}, fmt.Errorf("couldn't find or create %s: %s", estConfigFileName, err)
+		}
+	} else if !fileExists(estConfigFileName) {
+		return EstConfig{}, fmt.Errorf("couldn't find %s", estConfigFileName)
 	}
 
-	viper.SetConfigName(estConfigDefaultFileNameNoSuffix) // .toml suffix discovered automatically
-	viper.AddConfigPath("$HOME")
+	viper.SetConfigFile(estConfigFileName) // config type discovered from .toml suffix
 	if err := viper.ReadInConfig(); err != nil {
 		return EstConfig{}, err
 	}
 
 	c := EstConfig{}
-	err := viper.Unmarshal(&c)
-	return c, err
+	if err := viper.Unmarshal(&c); err != nil {
+		return EstConfig{}, err
+	}
+	if err := c.applyProfile(firstNonEmpty(configOverrides.Profile, os.Getenv(estProfileEnv))); err != nil {
+		return EstConfig{}, err
+	}
+	c.Storage = strings.ToLower(firstNonEmpty(c.Storage, StorageTOML))
+	if _, err := NewStorage(c.Storage, ""); err != nil {
+		return EstConfig{}, fmt.Errorf("invalid storage in %s: %s", estConfigFileName, err)
+	}
+	c.Estfile = firstNonEmpty(configOverrides.Estfile, os.Getenv(estFileEnv), c.Estfile, getDefaultEstFileName(c.Storage))
+	// An estfile's extension implies its storage, e.g. so that overriding the
+	// estfile with --estfile or $EST_FILE doesn't also require the storage.
+	c.Storage = firstNonEmpty(storageKindForFileName(c.Estfile), c.Storage)
+	if len(c.Workdays) == 0 {
+		c.Workdays = defaultWorkdays
+	}
+	if len(c.Workhours) == 0 {
+		c.Workhours = defaultWorkhours
+	}
+	if c.StaleMultiple == 0 {
+		c.StaleMultiple = defaultStaleMultiple
+	}
+	if c.StaleHours == 0 {
+		c.StaleHours = defaultStaleHours
+	}
+	c.Idle = strings.ToLower(firstNonEmpty(c.Idle, IdleOff))
+	if c.Idle != IdleOff && c.Idle != IdleAsk && c.Idle != IdlePause {
+		return EstConfig{}, fmt.Errorf("invalid idle in %s: '%s', expected \"off\", \"ask\" or \"pause\"", estConfigFileName, c.Idle)
+	}
+	if c.IdleMinutes <= 0 {
+		c.IdleMinutes = defaultIdleMinutes
+	}
+	loc := time.Local
+	if c.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(c.Timezone); err != nil {
+			return EstConfig{}, fmt.Errorf("invalid timezone in %s: %s", estConfigFileName, err)
+		}
+	}
+	wt, err := newWorkTimes(c.Workdays, c.Workhours, loc)
+	if err != nil {
+		return EstConfig{}, fmt.Errorf("invalid working hours in %s: %s", estConfigFileName, err)
+	}
+	c.workTimes = wt
+	return c, nil
+}
+
+// applyProfile overrides this estconfig with the settings of passed profile.
+func (ec *EstConfig) applyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	p, ok := ec.Profiles[strings.ToLower(name)] // estconfig keys are case-insensitive
+	if !ok {
+		return fmt.Errorf("no profile named '%s' in estconfig", name)
+	}
+	ec.profile = name
+	if p.Estfile != "" {
+		ec.Estfile = p.Estfile
+	}
+	if p.Storage != "" {
+		ec.Storage = p.Storage
+	}
+	if len(p.Workdays) > 0 {
+		ec.Workdays = p.Workdays
+	}
+	if len(p.Workhours) > 0 {
+		ec.Workhours = p.Workhours
+	}
+	if p.Timezone != "" {
+		ec.Timezone = p.Timezone
+	}
+	return nil
+}
+
+func newWorkTimes(workdays []string, workhours []string, loc *time.Location) (worktimes.WorkTimes, error) {
+	days := make(map[time.Weekday]bool, len(workdays))
+	for _, s := range workdays {
+		d, err := parseWeekday(s)
+		if err != nil {
+			return nil, err
+		}
+		days[d] = true
+	}
+	return worktimes.NewInLocation(days, workhours, loc)
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	s2 := strings.ToLower(strings.TrimSpace(s))
+	if len(s2) >= 3 {
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.HasPrefix(strings.ToLower(d.String()), s2) {
+				return d, nil
+			}
+		}
+	}
+	return 0, errors.New("invalid workday '" + s + "', expected e.g. \"monday\" or \"mon\"")
+}
+
+// getEstConfigFileName returns the expanded file name of the estconfig and
+// true iff that is a default location, rather than an override. Among
+// default locations, the legacy location is preferred if it exists.
+func getEstConfigFileName() (string, bool) {
+	if o := firstNonEmpty(configOverrides.ConfigFile, os.Getenv(estConfigEnv)); o != "" {
+		return expandPath(o), false
+	}
+	if fileExists(expandPath(estConfigLegacyFileName)) {
+		return expandPath(estConfigLegacyFileName), true
+	}
+	return expandPath(estConfigXDGFileName), true
+}
+
+// getDefaultEstFileName returns the estfile name used when the estconfig
+// doesn't specify one, preferring the legacy location if it exists. The
+// extension of the returned name matches the passed storage kind.
+func getDefaultEstFileName(storage string) string {
+	withExt := func(name string) string {
+		return strings.TrimSuffix(name, filepath.Ext(name)) + "." + storage
+	}
+	if fileExists(expandPath(withExt(estFileLegacyFileName))) {
+		return withExt(estFileLegacyFileName)
+	}
+	return withExt(estFileXDGFileName)
+}
+
+// expandPath returns the passed path with a leading "~" replaced by the
+// user's home directory and all environment variables expanded. XDG base
+// directory variables which are unset expand to their specified defaults.
+func expandPath(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		p = "$HOME" + p[1:]
+	}
+	return filepath.Clean(os.Expand(p, func(k string) string {
+		v := os.Getenv(k)
+		if v != "" {
+			return v
+		}
+		switch k {
+		case "XDG_CONFIG_HOME":
+			return filepath.Join(os.Getenv("HOME"), ".config")
+		case "XDG_DATA_HOME":
+			return filepath.Join(os.Getenv("HOME"), ".local", "share")
+		}
+		return ""
+	}))
+}
+
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
 }
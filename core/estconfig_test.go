@@ -0,0 +1,251 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandPath(t *testing.T) {
+	home := os.Getenv("HOME")
+	defer func() { _ = os.Setenv("HOME", home) }()
+	_ = os.Setenv("HOME", "/home/est")
+	_ = os.Setenv("EST_TEST_DRIVE", "/mnt/work")
+	_ = os.Unsetenv("XDG_DATA_HOME")
+
+	tcs := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"legacy $HOME", "$HOME/.estfile.toml", "/home/est/.estfile.toml"},
+		{"tilde", "~/estfile.toml", "/home/est/estfile.toml"},
+		{"tilde not at start", "/tmp/~/estfile.toml", "/tmp/~/estfile.toml"},
+		{"any env var", "$EST_TEST_DRIVE/est/estfile.toml", "/mnt/work/est/estfile.toml"},
+		{"braced env var", "${EST_TEST_DRIVE}/estfile.toml", "/mnt/work/estfile.toml"},
+		{"unset XDG var defaults", "$XDG_DATA_HOME/est/estfile.toml", "/home/est/.local/share/est/estfile.toml"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, expandPath(tc.input))
+		})
+	}
+}
+
+// withTestEstConfig calls passed fn with an estconfig file of passed
+// contents selected by --config, and without est's environment variables.
+func withTestEstConfig(t *testing.T, contents string, fn func(estConfigFileName string)) {
+	dir, err := ioutil.TempDir("", "est-estconfig-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	estConfigFileName := filepath.Join(dir, "estconfig.toml")
+	if err := ioutil.WriteFile(estConfigFileName, []byte(contents), estConfigMode); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{estConfigEnv, estFileEnv, estProfileEnv} {
+		v, ok := os.LookupEnv(k)
+		_ = os.Unsetenv(k)
+		if ok {
+			defer func(k, v string) { _ = os.Setenv(k, v) }(k, v)
+		}
+	}
+	defer SetConfigOverrides(ConfigOverrides{})
+	SetConfigOverrides(ConfigOverrides{ConfigFile: estConfigFileName})
+	fn(estConfigFileName)
+}
+
+func TestEstConfigTimezone(t *testing.T) {
+	contents := `estfile = "/tmp/est-test/estfile.toml"
+timezone = "America/New_York"
+[profiles.travel]
+timezone = "Asia/Tokyo"
+[profiles.home]
+estfile = "/tmp/est-test/home.toml"
+[profiles.typo]
+timezone = "Mars/Olympus_Mons"
+`
+	withTestEstConfig(t, contents, func(estConfigFileName string) {
+		local := time.Local
+		tcs := []struct {
+			name     string
+			profile  string
+			expected string
+		}{
+			{"timezone", "", "America/New_York"},
+			{"profile overrides timezone", "travel", "Asia/Tokyo"},
+			{"profile without timezone", "home", "America/New_York"},
+		}
+		for _, tc := range tcs {
+			t.Run(tc.name, func(t *testing.T) {
+				SetConfigOverrides(ConfigOverrides{ConfigFile: estConfigFileName, Profile: tc.profile})
+				ec, err := getEstConfig()
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, ec.WorkTimes().Location().String())
+				assert.Equal(t, local, time.Local, "process local time unchanged")
+			})
+		}
+		SetConfigOverrides(ConfigOverrides{ConfigFile: estConfigFileName, Profile: "typo"})
+		_, err := getEstConfig()
+		assert.Error(t, err, "invalid timezone")
+	})
+	withTestEstConfig(t, `estfile = "/tmp/est-test/estfile.toml"`, func(string) {
+		ec, err := getEstConfig()
+		assert.NoError(t, err)
+		assert.Equal(t, time.Local, ec.WorkTimes().Location(), "default is local timezone")
+	})
+}
+
+func TestConfigOverrides(t *testing.T) {
+	contents := `estfile = "/tmp/est-test/file.toml"
+[profiles.work]
+estfile = "/tmp/est-test/work.toml"
+[profiles.home]
+estfile = "/tmp/est-test/home.toml"
+`
+	withTestEstConfig(t, contents, func(estConfigFileName string) {
+		envConfigFileName := filepath.Join(filepath.Dir(estConfigFileName), "env-estconfig.toml")
+		if err := ioutil.WriteFile(envConfigFileName, []byte(`estfile = "/tmp/est-test/env-config.toml"`), estConfigMode); err != nil {
+			t.Fatal(err)
+		}
+		tcs := []struct {
+			name            string
+			env             map[string]string
+			overrides       ConfigOverrides
+			expectedEstfile string
+			expectedProfile string
+		}{
+			{"--config", nil, ConfigOverrides{ConfigFile: estConfigFileName}, "/tmp/est-test/file.toml", ""},
+			{"EST_CONFIG", map[string]string{estConfigEnv: envConfigFileName}, ConfigOverrides{}, "/tmp/est-test/env-config.toml", ""},
+			{"--config over EST_CONFIG", map[string]string{estConfigEnv: envConfigFileName}, ConfigOverrides{ConfigFile: estConfigFileName}, "/tmp/est-test/file.toml", ""},
+			{"EST_FILE over estconfig", map[string]string{estFileEnv: "/tmp/est-test/env.toml"}, ConfigOverrides{ConfigFile: estConfigFileName}, "/tmp/est-test/env.toml", ""},
+			{"--estfile over EST_FILE", map[string]string{estFileEnv: "/tmp/est-test/env.toml"}, ConfigOverrides{ConfigFile: estConfigFileName, Estfile: "/tmp/est-test/flag.toml"}, "/tmp/est-test/flag.toml", ""},
+			{"EST_PROFILE", map[string]string{estProfileEnv: "work"}, ConfigOverrides{ConfigFile: estConfigFileName}, "/tmp/est-test/work.toml", "work"},
+			{"--profile over EST_PROFILE", map[string]string{estProfileEnv: "work"}, ConfigOverrides{ConfigFile: estConfigFileName, Profile: "home"}, "/tmp/est-test/home.toml", "home"},
+			{"EST_FILE over profile", map[string]string{estFileEnv: "/tmp/est-test/env.toml", estProfileEnv: "work"}, ConfigOverrides{ConfigFile: estConfigFileName}, "/tmp/est-test/env.toml", "work"},
+			{"--estfile over profile", nil, ConfigOverrides{ConfigFile: estConfigFileName, Estfile: "/tmp/est-test/flag.toml", Profile: "home"}, "/tmp/est-test/flag.toml", "home"},
+		}
+		for _, tc := range tcs {
+			t.Run(tc.name, func(t *testing.T) {
+				for k, v := range tc.env {
+					_ = os.Setenv(k, v)
+					defer func(k string) { _ = os.Unsetenv(k) }(k)
+				}
+				SetConfigOverrides(tc.overrides)
+				ec, err := getEstConfig()
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedEstfile, ec.Estfile)
+				assert.Equal(t, tc.expectedProfile, ec.ProfileName())
+			})
+		}
+
+		SetConfigOverrides(ConfigOverrides{ConfigFile: estConfigFileName, Profile: "nope"})
+		_, err := getEstConfig()
+		assert.EqualError(t, err, "no profile named 'nope' in estconfig")
+		SetConfigOverrides(ConfigOverrides{ConfigFile: filepath.Join(filepath.Dir(estConfigFileName), "missing.toml")})
+		_, err = getEstConfig()
+		assert.Error(t, err, "missing --config file isn't created")
+	})
+}
+
+func TestEstfileExtensionImpliesStorage(t *testing.T) {
+	contents := `estfile = "/tmp/est-test/file.toml"
+storage = "toml"
+`
+	withTestEstConfig(t, contents, func(estConfigFileName string) {
+		tcs := []struct {
+			name            string
+			env             map[string]string
+			overrides       ConfigOverrides
+			expectedStorage string
+		}{
+			{"estconfig", nil, ConfigOverrides{ConfigFile: estConfigFileName}, StorageTOML},
+			{"--estfile", nil, ConfigOverrides{ConfigFile: estConfigFileName, Estfile: "/tmp/est-test/flag.jsonl"}, StorageJSONL},
+			{"EST_FILE", map[string]string{estFileEnv: "/tmp/est-test/env.JSONL"}, ConfigOverrides{ConfigFile: estConfigFileName}, StorageJSONL},
+			{"unknown extension keeps estconfig storage", nil, ConfigOverrides{ConfigFile: estConfigFileName, Estfile: "/tmp/est-test/flag.est"}, StorageTOML},
+		}
+		for _, tc := range tcs {
+			t.Run(tc.name, func(t *testing.T) {
+				for k, v := range tc.env {
+					_ = os.Setenv(k, v)
+					defer func(k string) { _ = os.Unsetenv(k) }(k)
+				}
+				SetConfigOverrides(tc.overrides)
+				ec, err := getEstConfig()
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedStorage, ec.Storage)
+				s, err := ec.storage()
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedStorage, s.Kind())
+			})
+		}
+	})
+}
+
+func TestApplyProfile(t *testing.T) {
+	base := EstConfig{
+		Estfile:   "/tmp/est-test/file.toml",
+		Storage:   StorageTOML,
+		Workdays:  []string{"mon", "tue"},
+		Workhours: []string{"9am-5pm"},
+		Timezone:  "America/New_York",
+		Profiles: map[string]EstConfigProfile{
+			"empty": {},
+			"work": {
+				Estfile:   "/tmp/est-test/work.jsonl",
+				Storage:   StorageJSONL,
+				Workdays:  []string{"wed"},
+				Workhours: []string{"10am-2pm"},
+				Timezone:  "Asia/Tokyo",
+			},
+		},
+	}
+	tcs := []struct {
+		name     string
+		profile  string
+		expected EstConfig
+		err      string
+	}{
+		{"no profile", "", base, ""},
+		{"empty profile overrides nothing", "empty", base, ""},
+		{"profile overrides all", "work", EstConfig{
+			Estfile:   "/tmp/est-test/work.jsonl",
+			Storage:   StorageJSONL,
+			Workdays:  []string{"wed"},
+			Workhours: []string{"10am-2pm"},
+			Timezone:  "Asia/Tokyo",
+		}, ""},
+		{"case-insensitive", "WORK", EstConfig{
+			Estfile:   "/tmp/est-test/work.jsonl",
+			Storage:   StorageJSONL,
+			Workdays:  []string{"wed"},
+			Workhours: []string{"10am-2pm"},
+			Timezone:  "Asia/Tokyo",
+		}, ""},
+		{"unknown profile", "nope", base, "no profile named 'nope' in estconfig"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ec := base
+			err := ec.applyProfile(tc.profile)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected.Estfile, ec.Estfile)
+			assert.Equal(t, tc.expected.Storage, ec.Storage)
+			assert.Equal(t, tc.expected.Workdays, ec.Workdays)
+			assert.Equal(t, tc.expected.Workhours, ec.Workhours)
+			assert.Equal(t, tc.expected.Timezone, ec.Timezone)
+			if tc.profile != "" {
+				assert.Equal(t, tc.profile, ec.ProfileName())
+			}
+		})
+	}
+}
@@ -4,9 +4,10 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -19,20 +20,144 @@ const estFileMode os.FileMode = 0600
 // Whereas Task is a wrapper around task, EstFile is a different data structure
 // than estFile. There's a bijection between EstFile <-> estFile.
 type EstFile struct {
-	Version int   // future use, to migrate old estfiles
+	Version int   // see migrateEstFile()
 	Tasks   tasks // a type alias for []*Task
 	// Fake ratios, see historicalEstimateAccuracyRatios().
 	// Fake ratios are saved to EstFile so they are stable.
 	FakeHistoricalEstimateAccuracyRatios []float64
+	// Accuracy ratios of archived tasks, see Archive().
+	ArchivedEstimateAccuracyRatios AccuracyRatios
 
-	fileName string // internal file name used to write back updated EstFile
+	storage  Storage  // internal storage used to write back updated EstFile
+	loaded   *estFile // estfile as last loaded from or saved to storage, shared by copies of this EstFile, see Write()
+	archived tasks    // tasks archived by Archive() to be written to the archive file, see Write()
 }
 
-func (ef EstFile) Write() error {
-	if ef.fileName == "" {
-		return errors.New("estFile.fileName was empty")
+// Write saves this EstFile back to the storage from which it was loaded. The
+// changes since this EstFile was loaded or last written are saved so that
+// this write can be undone, see Undo(). The passed description is shown to
+// the user on undo or redo. Tasks archived by Archive() are then added to the
+// archive file, and undo removes them from the archive file, so that undo
+// doesn't leave tasks in both the estfile and the archive file.
+func (ef EstFile) Write(description string) error {
+	return ef.writeUndoable(description, false)
+}
+
+// WriteAuto is Write for changes made automatically by est rather than by
+// the user's command, e.g. pausing idle tasks. An automatic change is undone
+// and redone along with the change made by the user's previous command, so
+// that it doesn't shadow that change in 'est undo'.
+func (ef EstFile) WriteAuto(description string) error {
+	return ef.writeUndoable(description, true)
+}
+
+func (ef EstFile) writeUndoable(description string, auto bool) error {
+	if ef.storage == nil {
+		return errors.New("estFile.storage was nil")
+	}
+	prev, err := ef.loadedEstFile()
+	if err != nil {
+		return err
+	}
+	next := toUnexportedEstfile(ef)
+	e := undoEntry{Description: description, When: time.Now(), Auto: auto}
+	e.setRestore(next, prev)
+	if !e.restoresEstfile() && len(ef.archived) == 0 {
+		// nothing changed, so there's nothing to undo
+		return nil
+	}
+	for _, t := range ef.archived {
+		e.Unarchive = append(e.Unarchive, t.ID().String())
+	}
+	if err := pushUndo(ef.storage.FileName(), e); err != nil {
+		return fmt.Errorf("couldn't save undo history: %s", err)
+	}
+	if err := ef.save(prev, next); err != nil {
+		return err
+	}
+	if len(ef.archived) > 0 {
+		if err := appendToArchiveFile(sidecarFileName(ef.storage.FileName(), "archive"), ef.archived); err != nil {
+			return fmt.Errorf("couldn't write archive file, use 'est undo' to restore archived tasks: %s", err)
+		}
+	}
+	return nil
+}
+
+// WriteWithoutUndo is Write for changes which are undone along with an
+// earlier write by the same command, e.g. each phase of 'est focus' is undone
+// along with starting the focus session, so that a long-running command
+// doesn't flood the undo history. Tasks archived by Archive() aren't written.
+func (ef EstFile) WriteWithoutUndo() error {
+	return ef.write()
+}
+
+// Reload loads this EstFile again from its storage, discarding unwritten
+// changes, e.g. so that a long-running command doesn't overwrite changes
+// made by other est commands.
+func (ef *EstFile) Reload() error {
+	if ef.storage == nil {
+		return errors.New("estFile.storage was nil")
+	}
+	loaded, err := getEstFile(ef.storage)
+	if err != nil {
+		return err
+	}
+	*ef = toExportedEstfile(loaded)
+	return nil
+}
+
+// write saves this EstFile without recording undo history.
+func (ef EstFile) write() error {
+	if ef.storage == nil {
+		return errors.New("estFile.storage was nil")
+	}
+	prev, err := ef.loadedEstFile()
+	if err != nil {
+		return err
+	}
+	return ef.save(prev, toUnexportedEstfile(ef))
+}
+
+// loadedEstFile returns the estfile as last loaded from or saved to this
+// EstFile's storage, without loading it again if possible.
+func (ef EstFile) loadedEstFile() (estFile, error) {
+	if ef.loaded != nil {
+		return *ef.loaded, nil
 	}
-	return ioutil.WriteFile(ef.fileName, []byte(encodeEstFile(toUnexportedEstfile(ef))), estFileMode)
+	return ef.storage.load()
+}
+
+// save saves passed next estfile to this EstFile's storage, replacing passed
+// prev estfile, and records it as the loaded estfile.
+func (ef EstFile) save(prev, next estFile) error {
+	saved, err := ef.storage.save(prev, next)
+	if err != nil {
+		return err
+	}
+	if ef.loaded != nil {
+		*ef.loaded = saved
+	}
+	return nil
+}
+
+// Storage returns the storage from which this EstFile was loaded, nil if it
+// was loaded read-only, see LoadEstFile().
+func (ef EstFile) Storage() Storage {
+	return ef.storage
+}
+
+// Convert saves this EstFile to the passed storage, which mustn't already
+// exist, e.g. to move an estfile to another kind of storage. Undo history
+// and other sidecar files aren't moved. This EstFile is unchanged.
+func (ef EstFile) Convert(to Storage) error {
+	if fileExists(to.FileName()) {
+		return fmt.Errorf("%s already exists", to.FileName())
+	}
+	if err := os.MkdirAll(filepath.Dir(to.FileName()), estDirMode); err != nil {
+		return err
+	}
+	_, err := to.save(estFile{}, toUnexportedEstfile(ef))
+	return err
 }
 
 // PadFakeHistoricalEstimateAccuracyRatios returns a copy of passed historical
@@ -50,8 +175,9 @@ func PadFakeHistoricalEstimateAccuracyRatios(rs, fakeRs []float64) []float64 {
 }
 
 // HistoricalEstimateAccuracyRatios returns the accuracy ratios for historical tasks
-// in this EstFile. Our definition of historical are tasks which are done and not
-// deleted. This returned []float64 is the "evidence" in "evidence-based scheduling".
+// in this EstFile, including archived tasks. Our definition of historical are tasks
+// which are estimated, done and not deleted. This returned []float64 is the
+// "evidence" in "evidence-based scheduling".
 func (ef EstFile) HistoricalEstimateAccuracyRatios() AccuracyRatios {
 	/*
 		TODO there is an argument to weight outcomes by magnitude of task estimate: larger estimates are often more important to a business and harder to get right. If an estimator's history was 90% accurate, but tasks which were estimated accurately are the smallest 90%, then it seems this estimator's history is less accurate than, say, someone who gets large estimates mostly accurate.
@@ -69,10 +195,22 @@ func (ef EstFile) HistoricalEstimateAccuracyRatios() AccuracyRatios {
 
 		Another argument is to match historical accuracy ratios of a certain size with future task estimates of a certain size. If an estimator is good or bad at estimating small tasks, let that reflect in small task predictions, and same for large. To impl this, we might use historicalEstimateAccuracyRatios :: [(EstimatedHours, Ratio)], so that downstream is able to weigh ratios with knowledge of the size of their estimates.
 	*/
-	ts := ef.Tasks.IsNotDeleted().IsDone().IsNonZeroActual()
-	ars := make(AccuracyRatios, len(ts))
-	for i := range ts {
-		ars[i] = ts[i].EstimateAccuracyRatio()
+	ars := historicalEstimateAccuracyRatios(ef.Tasks)
+	// Unestimated tasks may have been archived before they were excluded from
+	// historical tasks; their zero ratios would break downstream sampling.
+	return append(ars, filterAccuracyRatios(ef.ArchivedEstimateAccuracyRatios, func(ar *AccuracyRatio) bool {
+		return ar.duration > 0
+	})...)
+}
+
+// historicalEstimateAccuracyRatios returns the accuracy ratios of passed
+// tasks which are historical, see HistoricalEstimateAccuracyRatios(). These
+// ratios are non-zero: unestimated tasks aren't historical.
+func historicalEstimateAccuracyRatios(ts tasks) AccuracyRatios {
+	ts2 := ts.IsNotDeleted().IsEstimated().IsDone().IsNonZeroActual()
+	ars := make(AccuracyRatios, len(ts2))
+	for i := range ts2 {
+		ars[i] = ts2[i].EstimateAccuracyRatio()
 	}
 	return ars
 }
@@ -80,23 +218,32 @@ func (ef EstFile) HistoricalEstimateAccuracyRatios() AccuracyRatios {
 // estFile is the database for est. An estfile often corresponds to one user's
 // historical activity in est. A loaded .estfile is deserialized into this struct.
 type estFile struct {
-	Version int // future use, to migrate old estfiles
+	Version int // see migrateEstFile()
 	Tasks   []task
 	// Fake ratios, see historicalEstimateAccuracyRatios().
 	// Fake ratios are saved to EstFile so they are stable.
 	FakeHistoricalEstimateAccuracyRatios []float64
+	// Accuracy ratios of archived tasks, see Archive().
+	ArchivedEstimateAccuracyRatios []archivedAccuracyRatio `toml:",omitempty"`
 
-	fileName string // internal file name used to write back updated estFile
+	storage      Storage // internal storage used to write back updated estFile
+	jsonlRecords int     // number of records in the jsonl log from which this estFile was loaded, see jsonlStorage
 }
 
 func toExportedEstfile(ef estFile) EstFile {
 	fs := make([]float64, len(ef.FakeHistoricalEstimateAccuracyRatios))
 	copy(fs, ef.FakeHistoricalEstimateAccuracyRatios)
+	var loaded *estFile
+	if ef.storage != nil {
+		loaded = &ef
+	}
 	return EstFile{
 		Version: ef.Version,
 		Tasks:   toExportedTasks(ef.Tasks),
 		FakeHistoricalEstimateAccuracyRatios: fs,
-		fileName: ef.fileName,
+		ArchivedEstimateAccuracyRatios: fromArchivedAccuracyRatios(ef.ArchivedEstimateAccuracyRatios),
+		storage: ef.storage,
+		loaded:  loaded,
 	}
 }
 
@@ -107,23 +254,53 @@ func toUnexportedEstfile(ef EstFile) estFile {
 		Version: ef.Version,
 		Tasks:   toUnexportedTasks(ef.Tasks),
 		FakeHistoricalEstimateAccuracyRatios: fs,
-		fileName: ef.fileName,
+		ArchivedEstimateAccuracyRatios: toArchivedAccuracyRatios(ef.ArchivedEstimateAccuracyRatios),
+		storage: ef.storage,
 	}
 }
 
-func getEstFile(estFileName string) (estFile, error) {
-	if err := createFileWithDefaultContentsIfNotExists(estFileName, estFileMode, encodeEstFile(fakeEstfile())); err != nil {
-		return estFile{}, fmt.Errorf("couldn't find or create %s: %s", estFileName, err)
+// header returns this estfile's fields other than its tasks.
+func (ef estFile) header() estFile {
+	return estFile{
+		Version: ef.Version,
+		FakeHistoricalEstimateAccuracyRatios: ef.FakeHistoricalEstimateAccuracyRatios,
+		ArchivedEstimateAccuracyRatios:       ef.ArchivedEstimateAccuracyRatios,
 	}
+}
 
-	d, err := ioutil.ReadFile(estFileName)
+// getEstFile loads the estfile from passed storage, creating it if it
+// doesn't exist.
+func getEstFile(s Storage) (estFile, error) {
+	ef, err := s.load()
 	if err != nil {
 		return estFile{}, err
 	}
+	ef.storage = s
+	return ef, nil
+}
 
+func decodeEstFile(s string) (estFile, error) {
 	ef := estFile{}
-	_, err = toml.Decode(string(d), &ef)
-	return ef, err
+	if _, err := toml.Decode(s, &ef); err != nil {
+		return estFile{}, err
+	}
+	migrateEstFile(&ef)
+	return ef, nil
+}
+
+// estFileVersion is the version of newly created estfiles, see migrateEstFile().
+const estFileVersion = 2
+
+// migrateEstFile upgrades the passed estfile, which was just loaded, from
+// older versions. Migrated estfiles are saved by the next write. Version 2
+// records actual time as sessions, instead of one accumulated duration.
+func migrateEstFile(ef *estFile) {
+	if ef.Version < 2 {
+		for i := range ef.Tasks {
+			ef.Tasks[i].addLegacySession(SessionSourceMigrated)
+		}
+		ef.Version = 2
+	}
 }
 
 func encodeEstFile(ef estFile) string {
@@ -226,7 +403,7 @@ func fakeEstfile() estFile {
 	// t12 := newTask()
 	// t12.Name = "est show"
 	return estFile{
-		Version: 1,
+		Version: estFileVersion,
 		Tasks:   toUnexportedTasks(ts),
 		FakeHistoricalEstimateAccuracyRatios: makeFakeHistoricalEstimateAccuracyRatios(),
 	}
@@ -0,0 +1,229 @@
+package core
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportSchemaVersion is the version of the export schema, see Export.
+// It's incremented on any change to the schema which isn't backwards
+// compatible, i.e. anything other than adding fields.
+const ExportSchemaVersion = 1
+
+// ExportSchema documents the JSON encoding of an Export for users, e.g. in
+// 'est help export'. Comments aren't part of the encoding.
+const ExportSchema = `  {
+    "schema_version": 1,
+    "exported_at": "2018-01-31T09:30:00-07:00",
+    "tasks": [
+      {
+        "id": "3c6a8f20-...",
+        "name": "fix login bug #auth",
+        "tags": ["auth"],
+        "status": "done",             // deleted|done|paused|started|estimated|unestimated
+        "estimated_hours": 2,
+        "actual_hours": 2.5,
+        "accuracy_ratio": 0.8,        // null unless done with non-zero actual
+        "is_paused": false,
+        "is_done": true,
+        "is_deleted": false,
+        "created_at": "...",          // RFC 3339 times, null if never happened
+        "estimated_at": "...",
+        "started_at": "...",
+        "paused_at": null,
+        "done_at": "...",
+        "deleted_at": null,
+        "actual_updated_at": "...",   // last time this task had time tracked
+        "sessions": [                 // intervals of time worked, see 'est help session'
+          {
+            "id": "8d6d9a31-...",
+            "start": "...",
+            "end": "...",
+            "duration_hours": 2.5,    // sums to actual_hours
+            "share": 1,
+            "source": "auto"          // auto|manual|migrated|imported
+          }
+        ],
+        "events": [{"when": "...", "type": "...", "msg": "..."}]
+      }
+    ]
+  }
+`
+
+// Export is a machine-readable export of tasks. Its JSON encoding is a
+// stable schema on which other tools may build, see ExportSchema.
+type Export struct {
+	SchemaVersion int          `json:"schema_version"`
+	ExportedAt    time.Time    `json:"exported_at"`
+	Tasks         []ExportTask `json:"tasks"`
+}
+
+// ExportTask is one task in an Export. Times are RFC 3339 and null if the
+// thing never happened. Durations are in hours.
+type ExportTask struct {
+	ID              string          `json:"id"`
+	Name            string          `json:"name"`
+	Tags            []string        `json:"tags"`   // see Task.Tags()
+	Status          string          `json:"status"` // one of deleted, done, paused, started, estimated, unestimated
+	EstimatedHours  float64         `json:"estimated_hours"`
+	ActualHours     float64         `json:"actual_hours"`
+	AccuracyRatio   *float64        `json:"accuracy_ratio"` // estimated / actual, null unless task is done with non-zero actual
+	IsPaused        bool            `json:"is_paused"`
+	IsDone          bool            `json:"is_done"`
+	IsDeleted       bool            `json:"is_deleted"`
+	CreatedAt       *time.Time      `json:"created_at"`
+	EstimatedAt     *time.Time      `json:"estimated_at"`
+	StartedAt       *time.Time      `json:"started_at"`
+	PausedAt        *time.Time      `json:"paused_at"`
+	DoneAt          *time.Time      `json:"done_at"`
+	DeletedAt       *time.Time      `json:"deleted_at"`
+	ActualUpdatedAt *time.Time      `json:"actual_updated_at"` // last time this task had time tracked
+	Sessions        []ExportSession `json:"sessions"`          // intervals of time worked, whose durations sum to actual_hours
+	Events          []ExportEvent   `json:"events"`
+}
+
+// ExportSession is one interval of time worked on a task, see Session.
+type ExportSession struct {
+	ID            string    `json:"id"`
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	DurationHours float64   `json:"duration_hours"`
+	Share         float64   `json:"share"`
+	Source        string    `json:"source"` // one of auto, manual, migrated, imported
+}
+
+// ExportEvent is one entry in a task's history.
+type ExportEvent struct {
+	When time.Time `json:"when"`
+	Type string    `json:"type"`
+	Msg  string    `json:"msg"`
+}
+
+// exportCSVHeader is the CSV layout of an export, one task per row. Events
+// aren't included in CSV. Times are RFC 3339 and empty if the thing never
+// happened; accuracy_ratio is empty unless the task is done.
+var exportCSVHeader = []string{"id", "name", "tags", "status", "estimated_hours", "actual_hours", "accuracy_ratio", "is_paused", "is_done", "is_deleted", "created_at", "estimated_at", "started_at", "paused_at", "done_at", "deleted_at", "actual_updated_at"}
+
+// NewExport returns an export of the passed tasks.
+func NewExport(ts tasks, now time.Time) Export {
+	e := Export{
+		SchemaVersion: ExportSchemaVersion,
+		ExportedAt:    now,
+		Tasks:         make([]ExportTask, len(ts)),
+	}
+	for i, t := range ts {
+		e.Tasks[i] = newExportTask(t)
+	}
+	return e
+}
+
+func newExportTask(t *Task) ExportTask {
+	status, _ := t.status()
+	et := ExportTask{
+		ID:              t.ID().String(),
+		Name:            t.Name(),
+		Tags:            t.Tags(),
+		Status:          status.String(),
+		EstimatedHours:  t.Estimated().Hours(),
+		ActualHours:     t.Actual().Hours(),
+		IsPaused:        t.task.IsPaused,
+		IsDone:          t.task.IsDone,
+		IsDeleted:       t.task.IsDeleted,
+		CreatedAt:       exportTime(t.CreatedAt()),
+		EstimatedAt:     exportTime(t.EstimatedAt()),
+		StartedAt:       exportTime(t.StartedAt()),
+		PausedAt:        exportTime(t.PausedAt()),
+		DoneAt:          exportTime(t.DoneAt()),
+		DeletedAt:       exportTime(t.DeletedAt()),
+		ActualUpdatedAt: exportTime(t.task.ActualUpdatedAt),
+		Sessions:        make([]ExportSession, len(t.task.Sessions)),
+		Events:          make([]ExportEvent, len(t.task.Events)),
+	}
+	if et.Tags == nil {
+		et.Tags = []string{}
+	}
+	if t.IsDone() && t.Actual() > 0 {
+		r := t.estimateAccuracyRatio()
+		et.AccuracyRatio = &r
+	}
+	for i, s := range t.task.Sessions {
+		et.Sessions[i] = ExportSession{
+			ID:            s.ID.String(),
+			Start:         s.Start,
+			End:           s.End,
+			DurationHours: s.Duration.Hours(),
+			Share:         s.Share,
+			Source:        s.Source,
+		}
+	}
+	for i, ev := range t.task.Events {
+		et.Events[i] = ExportEvent{When: ev.When, Type: ev.Type, Msg: ev.Msg}
+	}
+	return et
+}
+
+func exportTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// WriteJSON writes this export as indented JSON.
+func (e Export) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e)
+}
+
+// WriteCSV writes this export as CSV with a header row.
+func (e Export) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(exportCSVHeader); err != nil {
+		return err
+	}
+	for _, t := range e.Tasks {
+		var ratio string
+		if t.AccuracyRatio != nil {
+			ratio = formatFloat(*t.AccuracyRatio)
+		}
+		if err := cw.Write([]string{
+			t.ID,
+			t.Name,
+			strings.Join(t.Tags, " "),
+			t.Status,
+			formatFloat(t.EstimatedHours),
+			formatFloat(t.ActualHours),
+			ratio,
+			strconv.FormatBool(t.IsPaused),
+			strconv.FormatBool(t.IsDone),
+			strconv.FormatBool(t.IsDeleted),
+			csvTime(t.CreatedAt),
+			csvTime(t.EstimatedAt),
+			csvTime(t.StartedAt),
+			csvTime(t.PausedAt),
+			csvTime(t.DoneAt),
+			csvTime(t.DeletedAt),
+			csvTime(t.ActualUpdatedAt),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+// assertGolden asserts that passed output equals golden file testdata/name,
+// or with -update, writes passed output to that file.
+func assertGolden(t *testing.T, name string, output []byte) {
+	fileName := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := ioutil.WriteFile(fileName, output, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(expected), string(output))
+}
+
+func TestExport(t *testing.T) {
+	// Monday 2018-01-01, 9am
+	mon := time.Date(2018, 1, 1, 9, 0, 0, 0, time.UTC)
+	mustParseUUID := func(s string) uuid.UUID {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	session := func(id string, start time.Time, d time.Duration, share float64, source string) Session {
+		s := newSession(start, start.Add(d), d, share, source)
+		s.ID = mustParseUUID(id)
+		return s
+	}
+	done := NewTask()
+	done.task.ID = mustParseUUID("3c6a8f20-0000-4000-8000-000000000001")
+	done.task.Name = "fix login bug #auth"
+	done.task.Estimated = 2 * time.Hour
+	done.task.Sessions = []Session{session("8d6d9a31-0000-4000-8000-000000000001", mon, 150*time.Minute, 1, SessionSourceManual)}
+	done.task.Events = []event{{When: mon, Type: "start", Msg: "started"}}
+	done.task.CreatedAt = mon.Add(-time.Hour)
+	done.task.EstimatedAt = mon.Add(-time.Hour)
+	done.task.StartedAt = mon
+	done.task.ActualUpdatedAt = mon.Add(150 * time.Minute)
+	done.task.IsDone = true
+	done.task.DoneAt = mon.Add(150 * time.Minute)
+
+	started := NewTask()
+	started.task.ID = mustParseUUID("3c6a8f20-0000-4000-8000-000000000002")
+	started.task.Name = "deploy"
+	started.task.Estimated = time.Hour
+	started.task.Sessions = []Session{session("8d6d9a31-0000-4000-8000-000000000002", mon, time.Hour, 0.5, SessionSourceAuto)}
+	started.task.Events = nil
+	started.task.CreatedAt = mon
+	started.task.EstimatedAt = mon
+	started.task.StartedAt = mon
+	started.task.ActualUpdatedAt = mon.Add(2 * time.Hour)
+
+	deleted := NewTask()
+	deleted.task.ID = mustParseUUID("3c6a8f20-0000-4000-8000-000000000003")
+	deleted.task.Name = "spike"
+	deleted.task.Events = nil
+	deleted.task.CreatedAt = mon
+	deleted.task.IsDeleted = true
+	deleted.task.DeletedAt = mon.Add(time.Hour)
+
+	e := NewExport(tasks{done, started, deleted}, mon.AddDate(0, 0, 1))
+	assert.Equal(t, []string{"done", "started", "deleted"}, []string{e.Tasks[0].Status, e.Tasks[1].Status, e.Tasks[2].Status})
+	assert.Equal(t, 0.8, *e.Tasks[0].AccuracyRatio)
+	assert.Nil(t, e.Tasks[1].AccuracyRatio, "not done")
+
+	var buf bytes.Buffer
+	assert.NoError(t, e.WriteJSON(&buf))
+	assertGolden(t, "export.json", buf.Bytes())
+	buf.Reset()
+	assert.NoError(t, e.WriteCSV(&buf))
+	assertGolden(t, "export.csv", buf.Bytes())
+
+	assert.True(t, strings.Contains(ExportSchema, fmt.Sprintf(`"schema_version": %d,`, ExportSchemaVersion)), "ExportSchema documents the current schema version")
+}
@@ -1,6 +1,14 @@
 package core
 
-import "os"
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
 
 func createFileWithDefaultContentsIfNotExists(filename string, fileMode os.FileMode, defaultContents string) error {
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
@@ -11,6 +19,9 @@ func createFileWithDefaultContentsIfNotExists(filename string, fileMode os.FileM
 		// filename exists, never overwrite
 		return nil
 	}
+	if err := os.MkdirAll(filepath.Dir(filename), estDirMode); err != nil {
+		return err
+	}
 	f, err := os.Create(filename)
 	if err != nil {
 		return err
@@ -22,3 +33,73 @@ func createFileWithDefaultContentsIfNotExists(filename string, fileMode os.FileM
 	_, err = f.Write([]byte(defaultContents))
 	return err
 }
+
+// fileExists returns true iff the passed file name exists.
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
+
+// sidecarFileName returns the name of a file of the passed kind which is
+// stored alongside the passed estfile, e.g. (".estfile.toml", "undo") ->
+// ".estfile.undo.toml". Sidecar files hold data that belongs to an estfile
+// but needn't be loaded on every invocation of est. Only a ".toml" extension
+// is dropped, so that estfiles with different storage don't share sidecars,
+// e.g. (".estfile.jsonl", "undo") -> ".estfile.jsonl.undo.toml".
+func sidecarFileName(estFileName, kind string) string {
+	return strings.TrimSuffix(estFileName, ".toml") + "." + kind + ".toml"
+}
+
+// readFileIfExists returns the contents of the passed file, or "" if it
+// doesn't exist.
+func readFileIfExists(name string) (string, error) {
+	d, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return string(d), err
+}
+
+// writeFileAtomic replaces the passed file with passed data atomically, by
+// writing a temporary file and renaming it, so that the file is never left
+// partially written.
+func writeFileAtomic(name string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }() // no-op after a successful rename
+	if err := f.Chmod(estFileMode); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// readSidecar decodes the passed sidecar file into passed v, see
+// sidecarFileName(). v is left unchanged if the file doesn't exist.
+func readSidecar(name string, v interface{}) error {
+	d, err := readFileIfExists(name)
+	if err != nil {
+		return err
+	}
+	_, err = toml.Decode(d, v)
+	return err
+}
+
+// writeSidecar encodes passed v to the passed sidecar file atomically, see
+// sidecarFileName() and writeFileAtomic().
+func writeSidecar(name string, v interface{}) error {
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	return writeFileAtomic(name, buf.Bytes())
+}
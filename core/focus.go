@@ -0,0 +1,202 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gizak/termui"
+)
+
+// Phases of a Focus.
+const (
+	FocusPhaseFocus = "focus" // working on the task
+	FocusPhaseBreak = "break" // taking a break, while the task is paused
+	FocusPhaseDone  = "done"  // all focus blocks completed
+)
+
+// eventTypeFocus is the event type of a completed focus block, see AddFocusBlock().
+const eventTypeFocus = "focus"
+
+// Focus is a timeboxed focus session on one task, i.e. focus blocks of
+// Length separated by breaks of Break, as in the pomodoro technique.
+type Focus struct {
+	Length     time.Duration // length of each focus block
+	Break      time.Duration // length of each break
+	Blocks     int           // number of focus blocks, zero for no limit
+	Completed  int           // number of focus blocks completed
+	Phase      string        // current phase, one of FocusPhaseFocus, etc.
+	PhaseStart time.Time     // time at which current phase started
+}
+
+// FocusChange is a change of phase of a Focus, see Advance().
+type FocusChange struct {
+	From string    // phase which ended
+	To   string    // phase which started
+	At   time.Time // time of the change
+}
+
+// NewFocus returns a new Focus whose first focus block starts at passed now.
+func NewFocus(length, brk time.Duration, blocks int, now time.Time) (*Focus, error) {
+	if length <= 0 {
+		return nil, errors.New("focus block length must be positive")
+	}
+	if brk < 0 {
+		return nil, errors.New("break length cannot be negative")
+	}
+	if blocks < 0 {
+		return nil, errors.New("number of focus blocks cannot be negative")
+	}
+	return &Focus{
+		Length:     length,
+		Break:      brk,
+		Blocks:     blocks,
+		Phase:      FocusPhaseFocus,
+		PhaseStart: now,
+	}, nil
+}
+
+// PhaseEnd returns the time at which the current phase ends.
+func (f *Focus) PhaseEnd() time.Time {
+	switch f.Phase {
+	case FocusPhaseFocus:
+		return f.PhaseStart.Add(f.Length)
+	case FocusPhaseBreak:
+		return f.PhaseStart.Add(f.Break)
+	}
+	return f.PhaseStart
+}
+
+// Advance moves this Focus through the phases which ended at or before
+// passed now, e.g. several phases if the machine slept, and returns the
+// changes of phase in order.
+func (f *Focus) Advance(now time.Time) []FocusChange {
+	var cs []FocusChange
+	for f.Phase != FocusPhaseDone && !f.PhaseEnd().After(now) {
+		c := FocusChange{From: f.Phase, At: f.PhaseEnd()}
+		switch {
+		case f.Phase == FocusPhaseBreak:
+			c.To = FocusPhaseFocus
+		case f.Blocks > 0 && f.Completed+1 >= f.Blocks:
+			c.To = FocusPhaseDone
+		case f.Break == 0:
+			c.To = FocusPhaseFocus
+		default:
+			c.To = FocusPhaseBreak
+		}
+		if c.From == FocusPhaseFocus {
+			f.Completed++
+		}
+		f.Phase = c.To
+		f.PhaseStart = c.At
+		cs = append(cs, c)
+	}
+	return cs
+}
+
+// AddFocusBlock records a focus block completed on this task between
+// passed start and end, in this task's history.
+func (t *Task) AddFocusBlock(start, end time.Time) {
+	t.task.Events = append(t.task.Events, event{
+		When: end,
+		Type: eventTypeFocus,
+		Msg:  fmt.Sprintf("focus block %s to %s", start.Local().Format(sessionTimeLayout), end.Local().Format("15:04")),
+	})
+}
+
+// FocusBlocks returns the number of focus blocks completed on this task.
+func (t *Task) FocusBlocks() int {
+	n := 0
+	for _, e := range t.task.Events {
+		if e.Type == eventTypeFocus {
+			n++
+		}
+	}
+	return n
+}
+
+// RenderFocusSummary returns a user-suitable summary of passed Focus on
+// passed task, comparing time focused to the task's estimate.
+func RenderFocusSummary(f *Focus, t *Task) string {
+	focused := time.Duration(f.Completed) * f.Length
+	s := fmt.Sprintf("Completed %d focus blocks of %.0fm on %s, %.2fh focused.", f.Completed, f.Length.Minutes(), t.Name(), focused.Hours())
+	s += fmt.Sprintf(" Actual %.2fh of estimate %.2fh", t.Actual().Hours(), t.Estimated().Hours())
+	if t.Estimated() > 0 {
+		s += fmt.Sprintf(" (%.0f%%)", 100*t.Actual().Hours()/t.Estimated().Hours())
+	}
+	return s + fmt.Sprintf(", %d focus blocks in total.\n", t.FocusBlocks())
+}
+
+// FocusTimer renders a full-terminal countdown of passed Focus on the task
+// with passed name, until the Focus is done or the user presses 'Q' to quit.
+// Each change of phase is passed to passed function, e.g. to pause the task
+// during breaks. Returns the first error returned by that function, which
+// ends the countdown.
+func FocusTimer(f *Focus, taskName string, onChange func(FocusChange) error) error {
+	title := termui.NewPar(taskName)
+	title.Height = 3
+	title.BorderLabel = "Focus"
+
+	gauge := termui.NewGauge()
+	gauge.Height = 3
+	gauge.BarColor = termui.ColorGreen
+
+	status := termui.NewPar("")
+	status.Height = 4
+	status.Border = false
+	status.PaddingLeft = 1
+
+	render := func(now time.Time) {
+		total := f.PhaseEnd().Sub(f.PhaseStart)
+		remaining := f.PhaseEnd().Sub(now)
+		if remaining < 0 {
+			remaining = 0
+		}
+		if total > 0 {
+			gauge.Percent = int(100 * (total - remaining) / total)
+		}
+		switch f.Phase {
+		case FocusPhaseBreak:
+			gauge.BorderLabel = "Break"
+			gauge.BarColor = termui.ColorBlue
+		default:
+			gauge.BorderLabel = "Focus"
+			gauge.BarColor = termui.ColorGreen
+		}
+		gauge.Label = fmt.Sprintf("%d:%02d remaining", int(remaining.Minutes()), int(remaining.Seconds())%60)
+		blocks := "unlimited"
+		if f.Blocks > 0 {
+			blocks = fmt.Sprintf("%d", f.Blocks)
+		}
+		status.Text = fmt.Sprintf("Completed %d of %s focus blocks\n\nQ to quit", f.Completed, blocks)
+		termui.Render(termui.Body)
+	}
+
+	var err error
+	tick := func(termui.Event) {
+		now := time.Now()
+		for _, c := range f.Advance(now) {
+			if err = onChange(c); err != nil {
+				termui.StopLoop()
+				return
+			}
+		}
+		if f.Phase == FocusPhaseDone {
+			termui.StopLoop()
+			return
+		}
+		render(now)
+	}
+	if err2 := runTerminalUI(func() []*termui.Row {
+		return []*termui.Row{
+			termui.NewRow(termui.NewCol(12, 0, title)),
+			termui.NewRow(termui.NewCol(12, 0, gauge)),
+			termui.NewRow(termui.NewCol(12, 0, status)),
+		}
+	}, func() {
+		render(time.Now())
+	}, map[string]func(termui.Event){"/timer/1s": tick}); err2 != nil {
+		return err2
+	}
+	return err
+}
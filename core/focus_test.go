@@ -0,0 +1,42 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFocus(t *testing.T) {
+	now := time.Date(2018, 1, 1, 10, 0, 0, 0, time.Local)
+	_, err := NewFocus(0, 5*time.Minute, 0, now)
+	assert.Error(t, err, "zero length")
+
+	f, err := NewFocus(25*time.Minute, 5*time.Minute, 2, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(f.Advance(now.Add(24*time.Minute))))
+	assert.Equal(t, []FocusChange{{FocusPhaseFocus, FocusPhaseBreak, now.Add(25 * time.Minute)}}, f.Advance(now.Add(25*time.Minute)))
+	assert.Equal(t, 1, f.Completed)
+
+	// e.g. the machine slept through the break and the second block.
+	cs := f.Advance(now.Add(2 * time.Hour))
+	assert.Equal(t, []FocusChange{
+		{FocusPhaseBreak, FocusPhaseFocus, now.Add(30 * time.Minute)},
+		{FocusPhaseFocus, FocusPhaseDone, now.Add(55 * time.Minute)},
+	}, cs)
+	assert.Equal(t, 2, f.Completed)
+	assert.Equal(t, FocusPhaseDone, f.Phase)
+	assert.Equal(t, 0, len(f.Advance(now.Add(3*time.Hour))), "done")
+
+	// Without breaks, focus blocks follow each other.
+	f, err = NewFocus(25*time.Minute, 0, 0, now)
+	assert.NoError(t, err)
+	cs = f.Advance(now.Add(time.Hour))
+	assert.Equal(t, 2, len(cs))
+	assert.Equal(t, FocusPhaseFocus, cs[1].To)
+	assert.Equal(t, 2, f.Completed)
+
+	tk := getStartedTask()
+	tk.AddFocusBlock(now, now.Add(25*time.Minute))
+	assert.Equal(t, 1, tk.FocusBlocks())
+}
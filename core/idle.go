@@ -0,0 +1,146 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ryanberckmans/est/core/worktimes"
+)
+
+// Idle policies, see EstConfig.Idle.
+const (
+	IdleOff   = "off"   // est-prompt doesn't record shell activity (default)
+	IdleAsk   = "ask"   // est asks whether to pause started tasks after an idle gap
+	IdlePause = "pause" // est pauses started tasks after an idle gap
+)
+
+// Default for estconfig idleminutes, see EstConfig.IdleThreshold().
+const defaultIdleMinutes = 60.0
+
+// idleMaxGaps is the max number of idle gaps kept until applied to tasks.
+// Older gaps are dropped so that the heartbeat file doesn't grow without
+// bound if est isn't run.
+const idleMaxGaps = 20
+
+// heartbeatFile is the recent shell activity for one estfile, recorded by
+// est-prompt. It's stored in a sidecar file so that recording activity on
+// every shell prompt is cheap and doesn't rewrite the estfile.
+type heartbeatFile struct {
+	Last time.Time // time of most recent shell activity
+	Gaps []IdleGap // idle gaps which haven't been applied to tasks, oldest first
+}
+
+// IdleGap is a period without shell activity while tasks were started.
+type IdleGap struct {
+	Start time.Time // last shell activity before the gap
+	End   time.Time // first shell activity after the gap
+}
+
+// Render returns a user-suitable description of this gap.
+func (g IdleGap) Render() string {
+	return fmt.Sprintf("no shell activity from %s to %s", g.Start.Local().Format(sessionTimeLayout), g.End.Local().Format(sessionTimeLayout))
+}
+
+// Heartbeat records shell activity on this EstFile at passed now. If there
+// was no shell activity for more than passed threshold of working time
+// while tasks were started, an idle gap is recorded, to be applied to tasks
+// by the next est command, see PauseIdle().
+func (ef *EstFile) Heartbeat(wt worktimes.WorkTimes, threshold time.Duration, now time.Time) error {
+	if ef.storage == nil {
+		return errors.New("estFile.storage was nil")
+	}
+	heartbeatFileName := sidecarFileName(ef.storage.FileName(), "heartbeat")
+	var h heartbeatFile
+	if err := readSidecar(heartbeatFileName, &h); err != nil {
+		return err
+	}
+	if !h.Last.IsZero() && wt.DurationBetween(h.Last, now) > threshold {
+		g := IdleGap{Start: h.Last, End: now}
+		if len(ef.Tasks.idleDuring(g)) > 0 {
+			h.Gaps = append(h.Gaps, g)
+			if len(h.Gaps) > idleMaxGaps {
+				h.Gaps = h.Gaps[len(h.Gaps)-idleMaxGaps:]
+			}
+		}
+	}
+	h.Last = now
+	return writeSidecar(heartbeatFileName, h)
+}
+
+// IdleGaps returns the idle gaps recorded for this EstFile which haven't
+// been applied to tasks, oldest first.
+func (ef *EstFile) IdleGaps() ([]IdleGap, error) {
+	if ef.storage == nil {
+		return nil, errors.New("estFile.storage was nil")
+	}
+	var h heartbeatFile
+	err := readSidecar(sidecarFileName(ef.storage.FileName(), "heartbeat"), &h)
+	return h.Gaps, err
+}
+
+// ClearIdleGaps forgets the idle gaps recorded for this EstFile, e.g. after
+// they were applied to tasks.
+func (ef *EstFile) ClearIdleGaps() error {
+	if ef.storage == nil {
+		return errors.New("estFile.storage was nil")
+	}
+	heartbeatFileName := sidecarFileName(ef.storage.FileName(), "heartbeat")
+	var h heartbeatFile
+	if err := readSidecar(heartbeatFileName, &h); err != nil {
+		return err
+	}
+	if len(h.Gaps) == 0 {
+		return nil
+	}
+	h.Gaps = nil
+	return writeSidecar(heartbeatFileName, h)
+}
+
+// idleDuring returns the started tasks among these tasks which were idle
+// during passed gap, i.e. which were started before the gap and have had no
+// activity since its start.
+func (ts tasks) idleDuring(g IdleGap) tasks {
+	var idle tasks
+	for _, t := range ts.IsStarted().IsNotDeleted() {
+		if !t.LastActivity().After(g.Start) {
+			idle = append(idle, t)
+		}
+	}
+	return idle
+}
+
+// IdleDuring returns the started tasks among these tasks which would be
+// paused by passed gaps, see PauseIdle().
+func (ts tasks) IdleDuring(gs []IdleGap) tasks {
+	var idle tasks
+	seen := map[*Task]bool{}
+	for _, g := range gs {
+		for _, t := range ts.idleDuring(g) {
+			if !seen[t] {
+				seen[t] = true
+				idle = append(idle, t)
+			}
+		}
+	}
+	return idle
+}
+
+// PauseIdle pauses the started tasks among these tasks which were idle
+// during passed gaps, as of the start of the first such gap, removing time
+// auto tracked on them since then, see PauseAsOf(). Tasks with activity
+// during a gap, e.g. time logged from another machine, aren't paused by it.
+// Returns the paused tasks.
+func (ts tasks) PauseIdle(wt worktimes.WorkTimes, gs []IdleGap, now time.Time) (tasks, error) {
+	var paused tasks
+	for _, g := range gs {
+		for _, t := range ts.idleDuring(g) {
+			i := ts.FindByIDPrefix(t.ID().String())
+			if err := ts.PauseAsOf(wt, i, g.Start, now); err != nil {
+				return nil, err
+			}
+			paused = append(paused, t)
+		}
+	}
+	return paused, nil
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ryanberckmans/est/core/worktimes"
+)
+
+func TestHeartbeat(t *testing.T) {
+	ef, cleanup := newTestEstFile(t)
+	defer cleanup()
+	wt := worktimes.GetAnonymousWorkTimes()
+	// Monday 2018-01-01, 10am
+	mon := time.Date(2018, 1, 1, 10, 0, 0, 0, time.Local)
+	tk := getStartedTask()
+	tk.task.StartedAt = mon
+	tk.task.ActualUpdatedAt = mon
+	ef.Tasks = tasks{tk}
+
+	assert.NoError(t, ef.Heartbeat(wt, time.Hour, mon))
+	assert.NoError(t, ef.Heartbeat(wt, time.Hour, mon.Add(30*time.Minute)))
+	gs, err := ef.IdleGaps()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(gs), "short gap isn't idle")
+
+	// Away from 10:30am to 10am the next day, which is 6.5 working hours.
+	tue := mon.AddDate(0, 0, 1)
+	assert.NoError(t, ef.Heartbeat(wt, time.Hour, tue))
+	assert.NoError(t, ef.Heartbeat(wt, time.Hour, tue.Add(time.Minute)))
+	gs, err = ef.IdleGaps()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(gs))
+	assert.True(t, gs[0].Start.Equal(mon.Add(30*time.Minute)))
+	assert.True(t, gs[0].End.Equal(tue))
+	assert.Equal(t, tasks{tk}, ef.Tasks.IdleDuring(gs))
+
+	paused, err := ef.Tasks.PauseIdle(wt, gs, tue.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, tasks{tk}, paused)
+	assert.True(t, tk.IsPaused())
+	assert.Equal(t, 30*time.Minute, tk.Actual(), "time after start of gap removed")
+
+	assert.NoError(t, ef.ClearIdleGaps())
+	gs, err = ef.IdleGaps()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(gs))
+
+	// Overnight isn't idle, because there are no working hours.
+	tk2 := getStartedTask()
+	tk2.task.StartedAt = time.Date(2018, 1, 2, 17, 0, 0, 0, time.Local)
+	ef.Tasks = tasks{tk2}
+	assert.NoError(t, ef.Heartbeat(wt, time.Hour, time.Date(2018, 1, 2, 17, 30, 0, 0, time.Local)))
+	assert.NoError(t, ef.Heartbeat(wt, time.Hour, time.Date(2018, 1, 3, 9, 30, 0, 0, time.Local)))
+	gs, err = ef.IdleGaps()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(gs))
+}
@@ -0,0 +1,436 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ryanberckmans/est/core/worktimes"
+)
+
+// ImportCSVLayout documents the CSV layout accepted by ImportTasksFromCSV.
+const ImportCSVLayout = `The first row is a header naming the columns, in any order. Columns:
+  name        required, task name
+  estimate    optional, estimated duration: hours "2.5", or "2.5h" or "150m"
+  actual      optional, actual duration, same syntax as estimate
+  created_at  optional, time task was created
+  started_at  optional, time task was started
+  done_at     optional, time task was done; if present, the task is done
+  id          optional, task ID (UUID), used to detect duplicates
+Times are "2018-01-31T14:00:00-07:00", "2018-01-31 14:00", or "2018-01-31" in
+local time. 'est export --format csv' output is also accepted.`
+
+// ImportTasksFromJSON parses tasks from either est's own JSON export (see
+// Export) or Taskwarrior's JSON export ('task export'). The format is detected
+// automatically. Passed WorkTimes are used to derive actual durations of
+// Taskwarrior tasks.
+func ImportTasksFromJSON(r io.Reader, wt worktimes.WorkTimes) (tasks, error) {
+	d, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	d = bytes.TrimSpace(d)
+	if len(d) > 0 && d[0] == '[' {
+		return importTaskwarrior(d, wt)
+	}
+	return importEstExport(d)
+}
+
+func importEstExport(d []byte) (tasks, error) {
+	e := Export{}
+	if err := json.Unmarshal(d, &e); err != nil {
+		return nil, fmt.Errorf("couldn't parse est export: %s", err)
+	}
+	if e.SchemaVersion < 1 || e.SchemaVersion > ExportSchemaVersion {
+		return nil, fmt.Errorf("unsupported est export schema_version %d", e.SchemaVersion)
+	}
+	ts := make(tasks, len(e.Tasks))
+	for i, et := range e.Tasks {
+		id, err := uuid.Parse(et.ID)
+		if err != nil {
+			return nil, fmt.Errorf("task %d: invalid id: %s", i, err)
+		}
+		t := &Task{task: task{
+			ID:              id,
+			Estimated:       hoursToDuration(et.EstimatedHours),
+			Actual:          hoursToDuration(et.ActualHours),
+			ActualUpdatedAt: importTime(et.ActualUpdatedAt),
+			IsPaused:        et.IsPaused,
+			IsDone:          et.IsDone,
+			IsDeleted:       et.IsDeleted,
+			CreatedAt:       importTime(et.CreatedAt),
+			EstimatedAt:     importTime(et.EstimatedAt),
+			StartedAt:       importTime(et.StartedAt),
+			PausedAt:        importTime(et.PausedAt),
+			DoneAt:          importTime(et.DoneAt),
+			DeletedAt:       importTime(et.DeletedAt),
+		}}
+		if err := t.SetName(truncateTaskName(et.Name)); err != nil {
+			return nil, fmt.Errorf("task %d: %s", i, err)
+		}
+		for j, es := range et.Sessions {
+			sid, err := uuid.Parse(es.ID)
+			if err != nil {
+				return nil, fmt.Errorf("task %d: session %d: invalid id: %s", i, j, err)
+			}
+			t.task.Sessions = append(t.task.Sessions, Session{
+				ID:       sid,
+				Start:    es.Start,
+				End:      es.End,
+				Duration: hoursToDuration(es.DurationHours),
+				Share:    es.Share,
+				Source:   es.Source,
+			})
+		}
+		if len(t.task.Sessions) > 0 {
+			t.task.Actual = 0 // actual_hours is the sum of sessions
+		}
+		t.task.addLegacySession(SessionSourceImported)
+		for _, ev := range et.Events {
+			t.task.Events = append(t.task.Events, event{When: ev.When, Type: ev.Type, Msg: ev.Msg})
+		}
+		if t.IsStarted() {
+			// An imported started task would auto track time since it was
+			// last tracked in another estfile; pause it instead.
+			t.task.IsPaused = true
+			t.task.PausedAt = t.task.ActualUpdatedAt
+		}
+		ts[i] = t
+	}
+	return ts, nil
+}
+
+// taskwarriorTask is the subset of a task in Taskwarrior's JSON export used
+// by est. "estimate" and "actual" are optional user defined attributes (UDAs)
+// in hours or Taskwarrior duration syntax, e.g. "PT2H30M".
+type taskwarriorTask struct {
+	UUID        string      `json:"uuid"`
+	Description string      `json:"description"`
+	Status      string      `json:"status"`
+	Entry       string      `json:"entry"`
+	Start       string      `json:"start"`
+	End         string      `json:"end"`
+	Tags        []string    `json:"tags"`
+	Estimate    interface{} `json:"estimate"`
+	Actual      interface{} `json:"actual"`
+}
+
+const taskwarriorTimeLayout = "20060102T150405Z"
+
+func importTaskwarrior(d []byte, wt worktimes.WorkTimes) (tasks, error) {
+	var tws []taskwarriorTask
+	if err := json.Unmarshal(d, &tws); err != nil {
+		return nil, fmt.Errorf("couldn't parse Taskwarrior export: %s", err)
+	}
+	var ts tasks
+	for i, tw := range tws {
+		if tw.Status == "recurring" {
+			continue // recurring tasks are templates for other tasks
+		}
+		name := tw.Description
+		for _, tag := range tw.Tags {
+			name += " #" + tag
+		}
+		var times [3]time.Time
+		for j, s := range []string{tw.Entry, tw.Start, tw.End} {
+			if s == "" {
+				continue
+			}
+			t, err := time.Parse(taskwarriorTimeLayout, s)
+			if err != nil {
+				return nil, fmt.Errorf("task %d: invalid time: %s", i, err)
+			}
+			times[j] = t.Local()
+		}
+		entry, start, end := times[0], times[1], times[2]
+		estimated, err := taskwarriorDuration(tw.Estimate)
+		if err != nil {
+			return nil, fmt.Errorf("task %d: invalid estimate: %s", i, err)
+		}
+		actual, err := taskwarriorDuration(tw.Actual)
+		if err != nil {
+			return nil, fmt.Errorf("task %d: invalid actual: %s", i, err)
+		}
+		if actual == 0 && !start.IsZero() && !end.IsZero() {
+			// Like auto time tracking, count only working hours.
+			actual = wt.DurationBetween(start, end)
+		}
+		if tw.Status != "completed" {
+			end = time.Time{}
+		}
+		t, err := newImportedTask(name, estimated, actual, entry, start, end, "Taskwarrior")
+		if err != nil {
+			return nil, fmt.Errorf("task %d: %s", i, err)
+		}
+		if id, err := uuid.Parse(tw.UUID); err == nil {
+			t.task.ID = id
+		}
+		if tw.Status == "deleted" {
+			t.task.IsDeleted = true
+			t.task.DeletedAt = latestTime(entry, start, times[2])
+		}
+		ts = append(ts, t)
+	}
+	return ts, nil
+}
+
+func taskwarriorDuration(v interface{}) (time.Duration, error) {
+	switch v2 := v.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return hoursToDuration(v2), nil
+	case string:
+		if strings.HasPrefix(v2, "P") {
+			return parseISO8601Duration(v2)
+		}
+		return parseImportDuration(v2)
+	}
+	return 0, fmt.Errorf("unexpected duration %v", v)
+}
+
+// parseISO8601Duration parses the subset of ISO 8601 durations used by
+// Taskwarrior, e.g. "PT2H30M" or "P1D". Days are 24 hours.
+func parseISO8601Duration(s string) (time.Duration, error) {
+	s2 := strings.TrimPrefix(s, "P")
+	var d time.Duration
+	inTime := false
+	for len(s2) > 0 {
+		if s2[0] == 'T' {
+			inTime = true
+			s2 = s2[1:]
+			continue
+		}
+		i := strings.IndexAny(s2, "DHMS")
+		if i < 1 {
+			return 0, fmt.Errorf("invalid duration %s", s)
+		}
+		f, err := strconv.ParseFloat(s2[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %s", s)
+		}
+		switch {
+		case s2[i] == 'D' && !inTime:
+			d += time.Duration(f * float64(24*time.Hour))
+		case s2[i] == 'H' && inTime:
+			d += time.Duration(f * float64(time.Hour))
+		case s2[i] == 'M' && inTime:
+			d += time.Duration(f * float64(time.Minute))
+		case s2[i] == 'S' && inTime:
+			d += time.Duration(f * float64(time.Second))
+		default:
+			return 0, fmt.Errorf("invalid duration %s", s)
+		}
+		s2 = s2[i+1:]
+	}
+	return d, nil
+}
+
+// ImportTasksFromCSV parses tasks from CSV, see ImportCSVLayout.
+func ImportTasksFromCSV(r io.Reader) (tasks, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 1 {
+		return nil, errors.New("CSV was empty, expected a header row")
+	}
+	cols := map[string]int{}
+	for i, h := range rows[0] {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	col := func(row []string, names ...string) string {
+		for _, n := range names {
+			if i, ok := cols[n]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+		}
+		return ""
+	}
+	if _, ok := cols["name"]; !ok {
+		return nil, errors.New("CSV header has no name column")
+	}
+	var ts tasks
+	for i, row := range rows[1:] {
+		line := i + 2
+		estimated, err := parseImportDuration(col(row, "estimate", "estimated_hours"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid estimate: %s", line, err)
+		}
+		actual, err := parseImportDuration(col(row, "actual", "actual_hours"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid actual: %s", line, err)
+		}
+		var times [3]time.Time
+		for j, c := range []string{"created_at", "started_at", "done_at"} {
+			times[j], err = parseImportTime(col(row, c))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s: %s", line, c, err)
+			}
+		}
+		t, err := newImportedTask(col(row, "name"), estimated, actual, times[0], times[1], times[2], "CSV")
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		if col(row, "is_deleted") == "true" {
+			t.task.IsDeleted = true
+			t.task.DeletedAt = latestTime(times[0], times[1], times[2])
+		}
+		if s := col(row, "id"); s != "" {
+			id, err := uuid.Parse(s)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid id: %s", line, err)
+			}
+			t.task.ID = id
+		}
+		ts = append(ts, t)
+	}
+	return ts, nil
+}
+
+// newImportedTask returns a well-formed task from passed data. The task is
+// done iff doneAt is non-zero; done tasks with non-zero estimate and actual
+// count as evidence for 'est schedule'. Zero createdAt and startedAt are
+// inferred from other times.
+func newImportedTask(name string, estimated, actual time.Duration, createdAt, startedAt, doneAt time.Time, source string) (*Task, error) {
+	t := NewTask()
+	if err := t.SetName(truncateTaskName(name)); err != nil {
+		return nil, err
+	}
+	if estimated < 0 || actual < 0 {
+		return nil, errors.New("durations cannot be negative")
+	}
+	now := time.Now()
+	if startedAt.IsZero() && !doneAt.IsZero() {
+		startedAt = doneAt.Add(-actual)
+	}
+	if createdAt.IsZero() {
+		createdAt = latestTime(startedAt, doneAt)
+		if createdAt.IsZero() {
+			createdAt = now
+		}
+	}
+	t.task.CreatedAt = createdAt
+	if estimated > 0 {
+		t.task.Estimated = estimated
+		t.task.EstimatedAt = createdAt
+	}
+	switch {
+	case !doneAt.IsZero():
+		t.task.StartedAt = startedAt
+		t.task.DoneAt = doneAt
+		t.task.ActualUpdatedAt = doneAt
+		t.task.Actual = actual
+		t.task.IsDone = true
+	case !startedAt.IsZero() || actual > 0:
+		// Started but not done: import as paused so that auto time
+		// tracking doesn't credit time since the task was exported.
+		if startedAt.IsZero() {
+			startedAt = createdAt
+		}
+		t.task.StartedAt = startedAt
+		t.task.PausedAt = startedAt
+		t.task.ActualUpdatedAt = startedAt
+		t.task.Actual = actual
+		t.task.IsPaused = true
+	}
+	t.task.addLegacySession(SessionSourceImported)
+	t.task.Events = append(t.task.Events, event{When: now, Type: "imported", Msg: "imported from " + source})
+	return t, nil
+}
+
+// Import adds the passed tasks to this EstFile, skipping duplicates. A task
+// is a duplicate if an existing task has the same ID, or the same name and
+// either both are not done or both were done at the same time. Returns the
+// added tasks and the skipped duplicates.
+func (ef *EstFile) Import(ts tasks) (added tasks, duplicates tasks) {
+	all := make(tasks, len(ef.Tasks), len(ef.Tasks)+len(ts))
+	copy(all, ef.Tasks)
+	for _, t := range ts {
+		if isDuplicateTask(all, t) {
+			duplicates = append(duplicates, t)
+			continue
+		}
+		added = append(added, t)
+		all = append(all, t)
+	}
+	ef.Tasks = all
+	return added, duplicates
+}
+
+func isDuplicateTask(ts tasks, t *Task) bool {
+	for _, t2 := range ts {
+		if t2.ID() == t.ID() {
+			return true
+		}
+		if t2.Name() != t.Name() || t2.IsDone() != t.IsDone() {
+			continue
+		}
+		if !t.IsDone() || t2.DoneAt().Truncate(time.Second).Equal(t.DoneAt().Truncate(time.Second)) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncateTaskName(n string) string {
+	return truncateName(strings.TrimSpace(n))
+}
+
+func hoursToDuration(h float64) time.Duration {
+	return time.Duration(h * float64(time.Hour))
+}
+
+func importTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// parseImportDuration parses "" (zero), hours "2.5", or "2.5h" or "150m".
+func parseImportDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	orig := s
+	unit := time.Hour
+	switch {
+	case strings.HasSuffix(s, "h"):
+		s = strings.TrimSuffix(s, "h")
+	case strings.HasSuffix(s, "m"):
+		s = strings.TrimSuffix(s, "m")
+		unit = time.Minute
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("duration %q is not a finite number", orig)
+	}
+	return time.Duration(f * float64(unit)), nil
+}
+
+var importTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"}
+
+func parseImportTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, l := range importTimeLayouts {
+		if t, err := time.ParseInLocation(l, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %s", s)
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ryanberckmans/est/core/worktimes"
+)
+
+func TestImportTasksFromCSV(t *testing.T) {
+	in := `name,estimate,actual,started_at,done_at
+fix the bug,2,3h,2018-01-30 09:30,2018-01-31 14:00
+write docs,90m,,,
+`
+	ts, err := ImportTasksFromCSV(strings.NewReader(in))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ts))
+	assert.True(t, ts[0].IsDone())
+	assert.Equal(t, 2*time.Hour, ts[0].Estimated())
+	assert.Equal(t, 3*time.Hour, ts[0].Actual())
+	assert.Equal(t, 1, len(EstFile{Tasks: ts}.HistoricalEstimateAccuracyRatios()), "imported done task counts as evidence")
+	assert.True(t, ts[1].IsEstimated())
+	assert.True(t, ts[1].IsNeverStarted())
+
+	_, err = ImportTasksFromCSV(strings.NewReader("estimate\n2\n"))
+	assert.Error(t, err, "name column is required")
+
+	for _, d := range []string{"NaN", "Inf", "-infh", "nanm"} {
+		_, err = ImportTasksFromCSV(strings.NewReader("name,estimate\nx," + d + "\n"))
+		assert.Error(t, err, "estimate %q isn't finite", d)
+	}
+
+	ts, err = ImportTasksFromCSV(strings.NewReader("name\n" + strings.Repeat("é", taskNameMaxLen) + "\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("é", taskNameMaxLen/2), ts[0].Name(), "long name truncated on a rune boundary")
+}
+
+func TestImportUnestimatedDoneTask(t *testing.T) {
+	wt := worktimes.GetAnonymousWorkTimes()
+	in := `name,estimate,actual,done_at
+old task,,3h,2018-01-10 12:00
+`
+	ts, err := ImportTasksFromCSV(strings.NewReader(in))
+	assert.NoError(t, err)
+	assert.True(t, ts[0].IsDone())
+	assert.False(t, ts[0].IsEstimated())
+	ef := EstFile{}
+	added, _ := ef.Import(ts)
+	assert.Equal(t, 1, len(added))
+	assert.Equal(t, 0, len(ef.HistoricalEstimateAccuracyRatios()), "unestimated done task isn't evidence")
+
+	todo := NewTask()
+	todo.task.Estimated = time.Hour
+	rs := PadFakeHistoricalEstimateAccuracyRatios(ef.HistoricalEstimateAccuracyRatios().Ratios(), []float64{0.5, 1, 2})
+	now := time.Date(2018, 1, 15, 10, 0, 0, 0, time.Local)
+	assert.NotPanics(t, func() {
+		dates := DeliverySchedule(wt, now, rs, tasks{todo})
+		assert.True(t, dates[99].After(now))
+	})
+}
+
+func TestImportTasksFromJSON(t *testing.T) {
+	wt := worktimes.GetAnonymousWorkTimes()
+	t.Run("taskwarrior", func(t *testing.T) {
+		in := `[
+{"uuid":"5f6a1c0e-3b7d-4f0e-9c3a-2d1e0f9a8b7c","description":"fix the bug","status":"completed","entry":"20180129T100000Z","start":"20180130T100000Z","end":"20180131T100000Z","tags":["auth"],"estimate":"PT2H30M","actual":3},
+{"uuid":"6a7b2d1f-4c8e-4a1f-8d4b-3e2f1a0b9c8d","description":"write docs","status":"pending","entry":"20180129T100000Z","estimate":1.5}
+]`
+		ts, err := ImportTasksFromJSON(strings.NewReader(in), wt)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(ts))
+		assert.Equal(t, "5f6a1c0e-3b7d-4f0e-9c3a-2d1e0f9a8b7c", ts[0].ID().String())
+		assert.Equal(t, "fix the bug #auth", ts[0].Name())
+		assert.True(t, ts[0].IsDone())
+		assert.Equal(t, 150*time.Minute, ts[0].Estimated())
+		assert.Equal(t, 3*time.Hour, ts[0].Actual())
+		assert.False(t, ts[1].IsDone())
+		assert.Equal(t, 90*time.Minute, ts[1].Estimated())
+	})
+	t.Run("est export round trip", func(t *testing.T) {
+		done := getStartedTask()
+		_ = done.SetName("fix the bug")
+		assert.NoError(t, tasks{done}.Done(wt, 0, time.Now().Add(time.Hour)))
+		started := getStartedTask()
+		_ = started.SetName("write docs")
+		var b strings.Builder
+		assert.NoError(t, NewExport(tasks{done, started}, time.Now()).WriteJSON(&b))
+		ts, err := ImportTasksFromJSON(strings.NewReader(b.String()), wt)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(ts))
+		assert.Equal(t, done.ID(), ts[0].ID())
+		assert.True(t, ts[0].IsDone())
+		assert.True(t, ts[1].IsPaused(), "started task imported as paused")
+
+		ef := EstFile{Tasks: tasks{done}}
+		added, duplicates := ef.Import(ts)
+		assert.Equal(t, 1, len(added))
+		assert.Equal(t, 1, len(duplicates), "done task has same ID")
+		assert.Equal(t, 2, len(ef.Tasks))
+	})
+}
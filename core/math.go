@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
 	"sort"
 )
@@ -56,3 +57,32 @@ func samples(rd *rand.Rand, historicalRatios []float64, toSamples []float64) flo
 func sample(rd *rand.Rand, historicalRatios []float64, toSample float64) float64 {
 	return toSample / historicalRatios[rd.Intn(len(historicalRatios))]
 }
+
+// median returns the median of passed values, which must be sorted and
+// non-empty. See percentile().
+func median(sorted []float64) float64 {
+	return percentile(sorted, 0.5)
+}
+
+// percentile returns the passed p-th quantile of passed values, which must be
+// sorted and non-empty, for 0 <= p <= 1, interpolating linearly between the
+// two nearest values, e.g. the mean of the middle two values as the median
+// of an even number of values.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		panic("percentile expected non-empty input")
+	}
+	h := p * float64(len(sorted)-1)
+	i := int(math.Floor(h))
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (h-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// withinAccuracy returns true iff passed accuracy ratio is within passed
+// fraction of a perfect estimate in either direction, e.g. within 0.2 (20%)
+// for ratios greater than 1/1.2 and less than 1.2.
+func withinAccuracy(ratio float64, fraction float64) bool {
+	return math.Max(ratio, 1/ratio) < 1+fraction
+}
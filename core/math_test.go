@@ -0,0 +1,26 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentile(t *testing.T) {
+	assert.Equal(t, 3.0, median([]float64{3}))
+	assert.Equal(t, 2.0, median([]float64{1, 2, 5}))
+	assert.Equal(t, 3.5, median([]float64{1, 2, 5, 8}), "mean of the middle two")
+	vs := []float64{0, 10, 20, 30, 40}
+	assert.Equal(t, 0.0, percentile(vs, 0))
+	assert.Equal(t, 32.0, percentile(vs, 0.8))
+	assert.Equal(t, 40.0, percentile(vs, 1))
+}
+
+func TestWithinAccuracy(t *testing.T) {
+	assert.True(t, withinAccuracy(1, 0.2))
+	assert.True(t, withinAccuracy(1.19, 0.2))
+	assert.True(t, withinAccuracy(1/1.19, 0.2), "overestimate")
+	assert.False(t, withinAccuracy(1.2, 0.2), "20% off isn't within 20%")
+	assert.False(t, withinAccuracy(1/1.2, 0.2))
+	assert.True(t, withinAccuracy(1.3, 0.5))
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MergeConflict is a difference between two copies of a task which merge
+// couldn't resolve automatically. Merge keeps our copy's value.
+type MergeConflict struct {
+	Task        *Task  // merged task
+	Explanation string // what conflicted and which value was kept
+}
+
+// MergeResult summarizes a merge, see Merge().
+type MergeResult struct {
+	Added     tasks           // tasks which existed only in their estfile
+	Updated   tasks           // tasks which existed in both estfiles and were changed by merge
+	Archived  tasks           // their tasks which we already archived, and so weren't added
+	Conflicts []MergeConflict // true conflicts, which kept our value
+}
+
+// Merge merges their EstFile into this EstFile, matching tasks by ID. Fields
+// of tasks in both estfiles are resolved using the time at which each field
+// was last changed, e.g. the estimate with the later EstimatedAt wins. Actual
+// time is the union of both copies' sessions, matched by session ID, so time
+// tracked prior to the copies diverging isn't double-counted. Merge is
+// symmetric except for true conflicts, in which case our value is kept and
+// the conflict reported. Their tasks which are in passed archived tasks, i.e.
+// our archive file, aren't added, so that they aren't both in our estfile
+// and archived, see Archive().
+func (ef *EstFile) Merge(their EstFile, archived tasks) MergeResult {
+	var r MergeResult
+	ourByID := make(map[string]*Task, len(ef.Tasks))
+	for _, t := range ef.Tasks {
+		ourByID[t.ID().String()] = t
+	}
+	archivedIDs := make(map[string]bool, len(archived))
+	for _, t := range archived {
+		archivedIDs[t.ID().String()] = true
+	}
+	for _, t2 := range their.Tasks {
+		t, ok := ourByID[t2.ID().String()]
+		if !ok && archivedIDs[t2.ID().String()] {
+			r.Archived = append(r.Archived, t2)
+			continue
+		}
+		if !ok {
+			t3 := &Task{task: t2.task}
+			ef.Tasks = append(ef.Tasks, t3)
+			ourByID[t3.ID().String()] = t3
+			r.Added = append(r.Added, t3)
+			continue
+		}
+		before := encodeTask(t.task)
+		r.Conflicts = append(r.Conflicts, mergeTask(t, t2)...)
+		if encodeTask(t.task) != before {
+			r.Updated = append(r.Updated, t)
+		}
+	}
+	return r
+}
+
+// mergeTask merges their copy of a task into our copy of the same task.
+func mergeTask(t *Task, t2 *Task) []MergeConflict {
+	var cs []MergeConflict
+	conflict := func(format string, a ...interface{}) {
+		cs = append(cs, MergeConflict{Task: t, Explanation: fmt.Sprintf(format, a...)})
+	}
+	ours, theirs := &t.task, &t2.task
+
+	// Name has no timestamp, so any difference is a true conflict.
+	if ours.Name != theirs.Name {
+		conflict("name differs, kept \"%s\" over \"%s\"", ours.Name, theirs.Name)
+	}
+
+	if theirs.CreatedAt.Before(ours.CreatedAt) && !theirs.CreatedAt.IsZero() {
+		ours.CreatedAt = theirs.CreatedAt
+	}
+
+	if theirs.EstimatedAt.After(ours.EstimatedAt) {
+		ours.Estimated = theirs.Estimated
+		ours.EstimatedAt = theirs.EstimatedAt
+	} else if theirs.EstimatedAt.Equal(ours.EstimatedAt) && theirs.Estimated != ours.Estimated {
+		conflict("estimated at the same time with different estimates, kept %.1fh over %.1fh", ours.Estimated.Hours(), theirs.Estimated.Hours())
+	}
+
+	ours.ActualUpdatedAt = latestTime(ours.ActualUpdatedAt, theirs.ActualUpdatedAt)
+	for _, s := range mergeSessions(ours, theirs) {
+		conflict("%s", s)
+	}
+
+	// Started, paused, and done are mutually exclusive, so the most recent
+	// transition among both copies determines the merged status.
+	if latestTime(theirs.StartedAt, theirs.PausedAt, theirs.DoneAt).After(latestTime(ours.StartedAt, ours.PausedAt, ours.DoneAt)) {
+		ours.IsPaused = theirs.IsPaused
+		ours.IsDone = theirs.IsDone
+		ours.Weight = theirs.Weight
+	} else if ours.Weight != theirs.Weight && !latestTime(ours.StartedAt, ours.PausedAt, ours.DoneAt).After(latestTime(theirs.StartedAt, theirs.PausedAt, theirs.DoneAt)) {
+		// Weight has no timestamp of its own, see SetWeight().
+		conflict("weight differs, kept %g over %g", (&Task{task: *ours}).Weight(), (&Task{task: *theirs}).Weight())
+	}
+	ours.StartedAt = latestTime(ours.StartedAt, theirs.StartedAt)
+	ours.PausedAt = latestTime(ours.PausedAt, theirs.PausedAt)
+	ours.DoneAt = latestTime(ours.DoneAt, theirs.DoneAt)
+
+	// Undelete doesn't record a time, so a difference in deletion with
+	// the same DeletedAt is a true conflict.
+	if theirs.DeletedAt.After(ours.DeletedAt) {
+		ours.IsDeleted = theirs.IsDeleted
+		ours.DeletedAt = theirs.DeletedAt
+	} else if theirs.DeletedAt.Equal(ours.DeletedAt) && theirs.IsDeleted != ours.IsDeleted {
+		conflict("deleted on one copy and undeleted on the other, kept deleted=%v", ours.IsDeleted)
+	}
+
+	ours.Events = mergeEvents(ours.Events, theirs.Events)
+	return cs
+}
+
+// mergeSessions merges their sessions into our sessions, returning a
+// description of each conflict. A session in both copies was extended
+// by auto time tracking or edited; the copy ending later is kept. Their
+// auto session which overlaps our auto session would double-count time
+// tracked on both copies while started, so it's a conflict. Removing a
+// session on one copy doesn't remove it from the other copy.
+func mergeSessions(ours, theirs *task) []string {
+	var cs []string
+	ourByID := make(map[string]int, len(ours.Sessions))
+	for i, s := range ours.Sessions {
+		ourByID[s.ID.String()] = i
+	}
+	for _, s2 := range theirs.Sessions {
+		i, ok := ourByID[s2.ID.String()]
+		if !ok {
+			if s := overlappingAutoSession(ours.Sessions, s2); s != nil {
+				cs = append(cs, fmt.Sprintf("auto tracked time overlaps on both copies, kept our session %s over their session %s", s.ID.String()[0:5], s2.ID.String()[0:5]))
+				continue
+			}
+			ours.Sessions = append(ours.Sessions, s2)
+			continue
+		}
+		s := ours.Sessions[i]
+		if s.Start.Equal(s2.Start) && s.End.Equal(s2.End) && s.Duration == s2.Duration && s.Share == s2.Share && s.Source == s2.Source {
+			continue
+		}
+		if s2.End.After(s.End) {
+			ours.Sessions[i] = s2
+		} else if s2.End.Equal(s.End) {
+			cs = append(cs, fmt.Sprintf("session %s differs on both copies, kept our %.1fh over their %.1fh", s.ID.String()[0:5], s.Duration.Hours(), s2.Duration.Hours()))
+		}
+	}
+	ours.sortSessions()
+	return cs
+}
+
+func overlappingAutoSession(ss []Session, s2 Session) *Session {
+	if s2.Source != SessionSourceAuto {
+		return nil
+	}
+	for i := range ss {
+		if ss[i].Source == SessionSourceAuto && ss[i].Start.Before(s2.End) && s2.Start.Before(ss[i].End) {
+			return &ss[i]
+		}
+	}
+	return nil
+}
+
+// mergeEvents returns the union of passed events, in order of time.
+func mergeEvents(es, es2 []event) []event {
+	seen := make(map[string]bool, len(es))
+	key := func(e event) string {
+		return e.When.Format(time.RFC3339Nano) + e.Type + e.Msg
+	}
+	var r []event
+	for _, e := range es {
+		seen[key(e)] = true
+		r = append(r, e)
+	}
+	for _, e := range es2 {
+		if !seen[key(e)] {
+			r = append(r, e)
+		}
+	}
+	sort.SliceStable(r, func(i, j int) bool {
+		return r[i].When.Before(r[j].When)
+	})
+	return r
+}
+
+// LoadEstFile loads the estfile with passed name, which must exist. Its
+// storage is inferred from its extension, see storageKindForFileName(), and
+// defaults to TOML.
+// Changes to a loaded EstFile are never written back. It's used to read an estfile
+// other than the user's own, e.g. for 'est merge'.
+func LoadEstFile(estFileName string) (EstFile, error) {
+	fileName := expandPath(estFileName)
+	if !fileExists(fileName) {
+		return EstFile{}, fmt.Errorf("couldn't find %s", estFileName)
+	}
+	s, err := NewStorage(firstNonEmpty(storageKindForFileName(fileName), StorageTOML), fileName)
+	if err != nil {
+		return EstFile{}, err
+	}
+	ef, err := s.load()
+	if err != nil {
+		return EstFile{}, err
+	}
+	return toExportedEstfile(ef), nil
+}
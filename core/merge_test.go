@@ -0,0 +1,75 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	base := getPausedTask()
+	now := time.Now()
+	base.task.Sessions = []Session{newSession(now.Add(-3*time.Hour), now.Add(-2*time.Hour), time.Hour, 1, SessionSourceAuto)}
+	withSession := func(t *Task, start time.Time, d time.Duration) {
+		t.task.Sessions = append(append([]Session{}, t.task.Sessions...), newSession(start, start.Add(d), d, 1, SessionSourceAuto))
+		t.task.ActualUpdatedAt = start.Add(d)
+	}
+
+	t.Run("later fields win and actual isn't double-counted", func(t *testing.T) {
+		ours := &Task{task: base.task}
+		theirs := &Task{task: base.task}
+		ours.task.Estimated = time.Hour * 3
+		ours.task.EstimatedAt = now.Add(time.Minute)
+		withSession(theirs, now.Add(-time.Hour), time.Hour)
+		theirs.task.IsPaused = false
+		theirs.task.IsDone = true
+		theirs.task.DoneAt = now.Add(time.Minute)
+		ef := EstFile{Tasks: tasks{ours}}
+		r := ef.Merge(EstFile{Tasks: tasks{theirs, getStartedTask()}}, nil)
+		assert.Equal(t, 1, len(r.Added))
+		assert.Equal(t, 1, len(r.Updated))
+		assert.Equal(t, 0, len(r.Conflicts))
+		assert.Equal(t, 2, len(ef.Tasks))
+		assert.Equal(t, time.Hour*3, ours.Estimated(), "our later estimate")
+		assert.Equal(t, time.Hour*2, ours.Actual(), "their new session added, shared session not double-counted")
+		assert.Equal(t, now, ours.task.ActualUpdatedAt, "their later time tracked")
+		assert.True(t, ours.IsDone(), "their later done")
+	})
+	t.Run("conflicts keep our value", func(t *testing.T) {
+		ours := &Task{task: base.task}
+		theirs := &Task{task: base.task}
+		ours.task.Name = "ours"
+		theirs.task.Name = "theirs"
+		withSession(ours, now.Add(-time.Hour), time.Hour)
+		withSession(theirs, now.Add(-time.Hour*3/2), time.Hour)
+		ef := EstFile{Tasks: tasks{ours}}
+		r := ef.Merge(EstFile{Tasks: tasks{theirs}}, nil)
+		assert.Equal(t, 2, len(r.Conflicts), "name and auto time tracked on both copies")
+		assert.Equal(t, "ours", ours.Name())
+		assert.Equal(t, time.Hour*2, ours.Actual())
+	})
+	t.Run("archived tasks aren't added", func(t *testing.T) {
+		ef, cleanup := newTestEstFile(t)
+		defer cleanup()
+		done := &Task{task: base.task}
+		done.task.IsDone = true
+		done.task.DoneAt = now.Add(-time.Hour)
+		done.task.Estimated = time.Hour
+		ef.Tasks = tasks{done}
+		assert.NoError(t, ef.Write("est add"))
+		theirs := &Task{task: done.task}
+		_, err := ef.Archive(now)
+		assert.NoError(t, err)
+		assert.NoError(t, ef.Write("est archive"))
+		archived, err := ef.ArchivedTasks()
+		assert.NoError(t, err)
+
+		r := ef.Merge(EstFile{Tasks: tasks{theirs, getStartedTask()}}, archived)
+		assert.Equal(t, 1, len(r.Added))
+		assert.Equal(t, 1, len(r.Archived))
+		assert.Equal(t, done.ID(), r.Archived[0].ID())
+		assert.Equal(t, 1, len(ef.Tasks), "archived task not added")
+		assert.Equal(t, 1, len(ef.HistoricalEstimateAccuracyRatios()), "archived task's ratio counted once")
+	})
+}
@@ -0,0 +1,225 @@
+package core
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ryanberckmans/est/core/worktimes"
+)
+
+// Report is a timesheet of hours worked on each task on each day, built
+// from tasks' sessions. See NewReport().
+type Report struct {
+	Days   []time.Time     // start of each day in this report, in order
+	Rows   []ReportRow     // one row per task with time worked in this report, in order of passed tasks
+	Totals []time.Duration // time worked on all tasks on each day, parallel to Days
+	Total  time.Duration   // time worked on all tasks in this report
+}
+
+// ReportRow is the time worked on one task on each day of a Report.
+type ReportRow struct {
+	Task  *Task
+	Days  []time.Duration // parallel to Report.Days
+	Total time.Duration
+}
+
+// NewReport returns a report of time worked on passed tasks on each day from
+// since up to but excluding until. Time is tracked up to passed now for
+// started tasks, without changing them. A session which spans multiple days,
+// e.g. one logged with 'est log', is divided between its days in proportion
+// to the working time on each day.
+func NewReport(wt worktimes.WorkTimes, ts tasks, since, until, now time.Time) Report {
+	r := Report{}
+	for d := worktimes.StartOfDay(since.In(wt.Location())); d.Before(until); d = worktimes.StartOfDay(d.AddDate(0, 0, 1)) {
+		r.Days = append(r.Days, d)
+	}
+	r.Totals = make([]time.Duration, len(r.Days))
+	if len(r.Days) == 0 {
+		return r
+	}
+
+	// Track time on copies of started tasks, so that the report includes time
+	// on started tasks since time was last tracked.
+	ts2 := make(tasks, len(ts))
+	for i, t := range ts {
+		t2 := &Task{task: t.task}
+		t2.task.Sessions = t.Sessions()
+		ts2[i] = t2
+	}
+	autoAddActual(wt, ts2.IsStarted().IsNotDeleted(), now)
+
+	for i, t := range ts2 {
+		row := ReportRow{Task: ts[i], Days: make([]time.Duration, len(r.Days))}
+		for _, s := range t.task.Sessions {
+			for day, d := range sessionDurationByDay(wt, s) {
+				j := dayIndex(r.Days, day)
+				if j < 0 {
+					continue
+				}
+				row.Days[j] += d
+				row.Total += d
+				r.Totals[j] += d
+				r.Total += d
+			}
+		}
+		if row.Total > 0 {
+			r.Rows = append(r.Rows, row)
+		}
+	}
+	return r
+}
+
+// sessionDurationByDay returns the passed session's duration divided between
+// the days on which it occurred, keyed by start of day. Duration is divided in
+// proportion to working time on each day, or to the time on each day if the
+// session has no working time.
+func sessionDurationByDay(wt worktimes.WorkTimes, s Session) map[time.Time]time.Duration {
+	start, end := s.Start.In(wt.Location()), s.End.In(wt.Location())
+	if !end.After(start) || worktimes.StartOfDay(start).Equal(worktimes.StartOfDay(end)) {
+		return map[time.Time]time.Duration{worktimes.StartOfDay(start): s.Duration}
+	}
+	total := wt.DurationBetween(start, end)
+	byWorkTime := total > 0
+	if !byWorkTime {
+		total = end.Sub(start)
+	}
+	m := make(map[time.Time]time.Duration)
+	var assigned time.Duration
+	for d := start; d.Before(end); {
+		next := worktimes.StartOfDay(d.AddDate(0, 0, 1))
+		if next.After(end) {
+			next = end
+		}
+		part := next.Sub(d)
+		if byWorkTime {
+			part = wt.DurationBetween(d, next)
+		}
+		if part > 0 {
+			share := time.Duration(float64(s.Duration) * float64(part) / float64(total))
+			m[worktimes.StartOfDay(d)] += share
+			assigned += share
+		}
+		d = next
+	}
+	// Assign any rounding error to the last day, so durations sum exactly.
+	m[worktimes.StartOfDay(end.Add(-time.Nanosecond))] += s.Duration - assigned
+	return m
+}
+
+func dayIndex(days []time.Time, day time.Time) int {
+	for i := range days {
+		if days[i].Equal(day) {
+			return i
+		}
+	}
+	return -1
+}
+
+// reportDayLayout is the layout of day column headings in a report.
+const reportDayLayout = "Mon 1/2"
+
+func reportHours(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1f", d.Hours())
+}
+
+// header returns the column headings of this report.
+func (r Report) header() []string {
+	h := []string{"ID", "TASK"}
+	for _, d := range r.Days {
+		h = append(h, d.Format(reportDayLayout))
+	}
+	return append(h, "TOTAL")
+}
+
+// WriteTable writes this report as an aligned table of hours.
+func (r Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	line := func(cells []string) {
+		fmt.Fprintln(tw, strings.Join(cells, "\t")+"\t")
+	}
+	line(r.header())
+	for _, row := range r.Rows {
+		cells := []string{row.Task.ID().String()[0:5], row.Task.Name()}
+		for _, d := range row.Days {
+			cells = append(cells, reportHours(d))
+		}
+		line(append(cells, reportHours(row.Total)))
+	}
+	cells := []string{"", "total"}
+	for _, d := range r.Totals {
+		cells = append(cells, reportHours(d))
+	}
+	line(append(cells, reportHours(r.Total)))
+	return tw.Flush()
+}
+
+// WriteCSV writes this report as CSV with a header row. Days are "YYYY-MM-DD"
+// and hours are decimal, e.g. "1.25".
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	h := []string{"id", "name"}
+	for _, d := range r.Days {
+		h = append(h, d.Format("2006-01-02"))
+	}
+	if err := cw.Write(append(h, "total")); err != nil {
+		return err
+	}
+	csvHours := func(d time.Duration) string {
+		return formatFloat(d.Hours())
+	}
+	for _, row := range r.Rows {
+		cells := []string{row.Task.ID().String(), row.Task.Name()}
+		for _, d := range row.Days {
+			cells = append(cells, csvHours(d))
+		}
+		if err := cw.Write(append(cells, csvHours(row.Total))); err != nil {
+			return err
+		}
+	}
+	cells := []string{"", "total"}
+	for _, d := range r.Totals {
+		cells = append(cells, csvHours(d))
+	}
+	if err := cw.Write(append(cells, csvHours(r.Total))); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMarkdown writes this report as a markdown table.
+func (r Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	line := func(cells []string) {
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	h := r.header()
+	line(h)
+	align := []string{"---", "---"}
+	for i := 2; i < len(h); i++ {
+		align = append(align, "---:")
+	}
+	line(align)
+	escape := strings.NewReplacer("|", "\\|")
+	for _, row := range r.Rows {
+		cells := []string{row.Task.ID().String()[0:5], escape.Replace(row.Task.Name())}
+		for _, d := range row.Days {
+			cells = append(cells, reportHours(d))
+		}
+		line(append(cells, reportHours(row.Total)))
+	}
+	cells := []string{"", "**total**"}
+	for _, d := range r.Totals {
+		cells = append(cells, reportHours(d))
+	}
+	line(append(cells, "**"+reportHours(r.Total)+"**"))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
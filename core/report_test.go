@@ -0,0 +1,62 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ryanberckmans/est/core/worktimes"
+)
+
+func TestReport(t *testing.T) {
+	wt := worktimes.GetAnonymousWorkTimes()
+	// Monday 2018-01-01
+	mon := time.Date(2018, 1, 1, 0, 0, 0, 0, time.Local)
+
+	paused := getPausedTask()
+	paused.task.Sessions = []Session{
+		newSession(mon.Add(10*time.Hour), mon.Add(12*time.Hour), 2*time.Hour, 1, SessionSourceAuto),
+		// spans Monday 4pm to Tuesday noon, divided by working time
+		newSession(mon.Add(16*time.Hour), mon.AddDate(0, 0, 1).Add(12*time.Hour), 3*time.Hour, 1, SessionSourceManual),
+		// outside of report
+		newSession(mon.AddDate(0, 0, -3), mon.AddDate(0, 0, -3).Add(time.Hour), time.Hour, 1, SessionSourceManual),
+	}
+	started := getStartedTask()
+	started.task.ActualUpdatedAt = mon.AddDate(0, 0, 2).Add(10 * time.Hour)
+	idle := getPausedTask()
+
+	now := mon.AddDate(0, 0, 2).Add(11 * time.Hour)
+	r := NewReport(wt, tasks{paused, started, idle}, mon, mon.AddDate(0, 0, 7), now)
+	assert.Equal(t, 7, len(r.Days))
+	assert.Equal(t, 2, len(r.Rows), "tasks without time in report omitted")
+
+	monWork := wt.DurationBetween(mon.Add(16*time.Hour), mon.AddDate(0, 0, 1))
+	tueWork := wt.DurationBetween(mon.AddDate(0, 0, 1), mon.AddDate(0, 0, 1).Add(12*time.Hour))
+	monShare := time.Duration(float64(3*time.Hour) * float64(monWork) / float64(monWork+tueWork))
+	assert.Equal(t, 2*time.Hour+monShare, r.Rows[0].Days[0])
+	assert.Equal(t, 3*time.Hour-monShare, r.Rows[0].Days[1])
+	assert.Equal(t, 5*time.Hour, r.Rows[0].Total, "time outside of report excluded")
+
+	assert.Equal(t, time.Hour, r.Rows[1].Days[2], "started task tracked up to now")
+	assert.Equal(t, 0, len(started.Sessions()), "started task unchanged")
+
+	assert.Equal(t, 6*time.Hour, r.Total)
+	assert.Equal(t, time.Hour, r.Totals[2])
+
+	var b bytes.Buffer
+	assert.NoError(t, r.WriteCSV(&b))
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	assert.Equal(t, 4, len(lines), "header, two tasks, totals")
+	assert.Equal(t, "id,name,2018-01-01,2018-01-02,2018-01-03,2018-01-04,2018-01-05,2018-01-06,2018-01-07,total", lines[0])
+	assert.True(t, strings.HasSuffix(lines[3], ",6"), lines[3])
+
+	b.Reset()
+	assert.NoError(t, r.WriteMarkdown(&b))
+	assert.Contains(t, b.String(), "| **total** |")
+	b.Reset()
+	assert.NoError(t, r.WriteTable(&b))
+	assert.Contains(t, b.String(), "Mon 1/1")
+}
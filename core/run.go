@@ -3,31 +3,60 @@ package core
 import (
 	"fmt"
 	"os"
-	"strings"
 )
 
+// estConfigAndFile is the estconfig and estfile loaded by
+// WithEstConfigAndFile(), shared by all its calls in this process.
+type estConfigAndFile struct {
+	ec     *EstConfig
+	ef     *EstFile
+	failed bool // true iff loading failed
+}
+
+var loadedEstConfigAndFile estConfigAndFile
+
 // WithEstConfigAndFile is the standard entrypoint into est/core.
 // Loads or creates a canonical estconfig and estfile, then passes
-// them to the passed function.
+// them to the passed function. They're loaded once per process, so
+// that est's hooks run before and after each command share them with
+// the command, and a failure to load them is printed once.
 // TODO drop the With() and should just be (es, ef, error)
 // TODO ensure all fatal/errors in entire app written to stderr
 func WithEstConfigAndFile(fn func(ec *EstConfig, ef *EstFile), failFn func()) {
+	l := &loadedEstConfigAndFile
+	if l.failed {
+		failFn()
+		return
+	}
+	if l.ef != nil {
+		fn(l.ec, l.ef)
+		return
+	}
+
 	ec, err := getEstConfig()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "fatal: %s", err)
+		fmt.Fprintf(os.Stderr, "fatal: %s\n", err)
+		l.failed = true
 		failFn()
 		return
 	}
 
-	estFileName := strings.Replace(ec.Estfile, "$HOME", os.Getenv("HOME"), -1)
-	ef, err := getEstFile(estFileName) // TODO support replacement of any env
+	s, err := ec.storage()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %s\n", err)
+		l.failed = true
+		failFn()
+		return
+	}
+	ef, err := getEstFile(s)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "fatal: %s", err)
+		fmt.Fprintf(os.Stderr, "fatal: %s\n", err)
+		l.failed = true
 		failFn()
 		return
 	}
-	ef.fileName = estFileName
 
 	ef2 := toExportedEstfile(ef)
-	fn(&ec, &ef2)
+	l.ec, l.ef = &ec, &ef2
+	fn(l.ec, l.ef)
 }
@@ -27,7 +27,26 @@ func DeliverySchedule(wt worktimes.WorkTimes, now time.Time, historicalEstimateA
 	for i := range ts {
 		toSamples = append(toSamples, ts[i].Estimated().Hours())
 	}
+	return deliverySchedule(wt, now, historicalEstimateAccuracyRatios, toSamples)
+}
+
+// RemainingDeliverySchedule is like DeliverySchedule, but for the remaining
+// estimated work of passed tasks, so that started tasks are scheduled only for
+// their estimate less their actual time. Tasks over their estimate have no
+// remaining work.
+func RemainingDeliverySchedule(wt worktimes.WorkTimes, now time.Time, historicalEstimateAccuracyRatios []float64, ts tasks) [100]time.Time {
+	var toSamples []float64
+	for i := range ts {
+		remaining := ts[i].Estimated() - ts[i].Actual()
+		if remaining < 0 {
+			remaining = 0
+		}
+		toSamples = append(toSamples, remaining.Hours())
+	}
+	return deliverySchedule(wt, now, historicalEstimateAccuracyRatios, toSamples)
+}
 
+func deliverySchedule(wt worktimes.WorkTimes, now time.Time, historicalEstimateAccuracyRatios []float64, toSamples []float64) [100]time.Time {
 	samples := sampleDistribution(100, rand.New(rand.NewSource(now.UnixNano())), historicalEstimateAccuracyRatios, toSamples)
 
 	pct := toPercentile(samples) // after writing toPercentile(), realized that the statistical significance of the distribution may change if the iterations in sampleDistribution() differ from 100. I.e. if you do 10k iterations, then pct[99] is going to represent a 1 in 10,000 case, which isn't what the user expects. So toPercentile() isn't too useful because the percentile result model only makes sense if 1% actually means 1 in 100. Right?
@@ -0,0 +1,186 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ryanberckmans/est/core/worktimes"
+)
+
+// scheduleHistoryMaxSnapshots is the max number of snapshots kept in a
+// schedule history, e.g. a year of daily schedules. Older snapshots are
+// dropped so that the history file doesn't grow without bound.
+const scheduleHistoryMaxSnapshots = 365
+
+// scheduleHistoryFile holds snapshots of predicted delivery schedules for
+// one estfile, see RecordSchedule(). It's stored in a sidecar file so that
+// schedule history isn't loaded on every invocation of est.
+type scheduleHistoryFile struct {
+	Snapshots ScheduleHistory
+}
+
+// ScheduleHistory is snapshots of predicted delivery schedules, oldest first,
+// to show how the schedule moved over time.
+type ScheduleHistory []ScheduleSnapshot
+
+// ScheduleSnapshot is a predicted delivery schedule as of one run of 'est
+// schedule'. See NewScheduleSnapshot().
+type ScheduleSnapshot struct {
+	At    time.Time              // when the schedule was predicted
+	P50   time.Time              // 50% likely to deliver all tasks by this date
+	P80   time.Time              // 80% likely
+	P95   time.Time              // 95% likely
+	Tasks []ScheduleSnapshotTask // tasks in the schedule
+}
+
+// ScheduleSnapshotTask is a task in a ScheduleSnapshot, as it was when the
+// schedule was predicted.
+type ScheduleSnapshotTask struct {
+	ID        string
+	Name      string
+	Estimated time.Duration
+}
+
+// NewScheduleSnapshot returns a snapshot of passed delivery schedule of
+// passed tasks predicted at passed now, see DeliverySchedule().
+func NewScheduleSnapshot(dates [100]time.Time, ts tasks, now time.Time) ScheduleSnapshot {
+	s := ScheduleSnapshot{At: now, P50: dates[49], P80: dates[79], P95: dates[94]}
+	for _, t := range ts {
+		s.Tasks = append(s.Tasks, ScheduleSnapshotTask{ID: t.ID().String(), Name: t.Name(), Estimated: t.Estimated()})
+	}
+	return s
+}
+
+// Estimated returns the total estimated work of the tasks in this snapshot.
+func (s ScheduleSnapshot) Estimated() time.Duration {
+	var d time.Duration
+	for _, t := range s.Tasks {
+		d += t.Estimated
+	}
+	return d
+}
+
+// scopeChange returns the number of tasks added to and removed from the
+// schedule since passed previous snapshot, and the number of tasks in both
+// which were re-estimated.
+func (s ScheduleSnapshot) scopeChange(prev ScheduleSnapshot) (added, removed, reestimated int) {
+	prevEstimates := make(map[string]time.Duration, len(prev.Tasks))
+	for _, t := range prev.Tasks {
+		prevEstimates[t.ID] = t.Estimated
+	}
+	for _, t := range s.Tasks {
+		e, ok := prevEstimates[t.ID]
+		switch {
+		case !ok:
+			added++
+		case e != t.Estimated:
+			reestimated++
+		}
+		delete(prevEstimates, t.ID)
+	}
+	return added, len(prevEstimates), reestimated
+}
+
+// RecordSchedule appends passed snapshot to this EstFile's schedule history.
+// If the most recent snapshot was taken on the same day in passed working
+// location with the same tasks and estimates, it's replaced instead, so that
+// running 'est schedule' repeatedly doesn't flood the history. At most
+// scheduleHistoryMaxSnapshots are kept. The history file is written
+// immediately.
+func (ef *EstFile) RecordSchedule(s ScheduleSnapshot, loc *time.Location) error {
+	if ef.storage == nil {
+		return errors.New("estFile.storage was nil")
+	}
+	scheduleHistoryFileName := sidecarFileName(ef.storage.FileName(), "schedule-history")
+	var hf scheduleHistoryFile
+	if err := readSidecar(scheduleHistoryFileName, &hf); err != nil {
+		return err
+	}
+	if n := len(hf.Snapshots); n > 0 {
+		last := hf.Snapshots[n-1]
+		added, removed, reestimated := s.scopeChange(last)
+		if added+removed+reestimated == 0 && worktimes.StartOfDay(last.At.In(loc)).Equal(worktimes.StartOfDay(s.At.In(loc))) {
+			hf.Snapshots = hf.Snapshots[:n-1]
+		}
+	}
+	hf.Snapshots = append(hf.Snapshots, s)
+	if len(hf.Snapshots) > scheduleHistoryMaxSnapshots {
+		hf.Snapshots = hf.Snapshots[len(hf.Snapshots)-scheduleHistoryMaxSnapshots:]
+	}
+	return writeSidecar(scheduleHistoryFileName, hf)
+}
+
+// ScheduleHistory returns this EstFile's schedule history, see
+// RecordSchedule().
+func (ef EstFile) ScheduleHistory() (ScheduleHistory, error) {
+	if ef.storage == nil {
+		return nil, errors.New("estFile.storage was nil")
+	}
+	var hf scheduleHistoryFile
+	if err := readSidecar(sidecarFileName(ef.storage.FileName(), "schedule-history"), &hf); err != nil {
+		return nil, err
+	}
+	return hf.Snapshots, nil
+}
+
+// Since returns the snapshots in this history taken at or after passed time.
+func (h ScheduleHistory) Since(t time.Time) ScheduleHistory {
+	var h2 ScheduleHistory
+	for _, s := range h {
+		if !s.At.Before(t) {
+			h2 = append(h2, s)
+		}
+	}
+	return h2
+}
+
+// renderDrift returns a user-suitable number of calendar days from passed
+// previous predicted date to passed predicted date, e.g. "+3d" if the
+// prediction slipped by three days.
+func renderDrift(prev, t time.Time) string {
+	return fmt.Sprintf("%+dd", int(math.Round(t.Sub(prev).Hours()/24)))
+}
+
+// RenderSummary returns a user-suitable one line summary of how the 50%
+// predicted date moved over this history.
+func (h ScheduleHistory) RenderSummary() string {
+	if len(h) == 0 {
+		return "no schedule history, see 'est schedule'"
+	}
+	first, last := h[0], h[len(h)-1]
+	if len(h) == 1 {
+		return fmt.Sprintf("1 schedule recorded on %s, 50%% predicted date %s", first.At.Local().Format("Jan 2"), first.P50.Format("Jan 2"))
+	}
+	added, removed, reestimated := last.scopeChange(first)
+	return fmt.Sprintf("50%% predicted date moved %s, from %s to %s, over %d schedules since %s; %d tasks added, %d removed, %d re-estimated",
+		renderDrift(first.P50, last.P50), first.P50.Format("Jan 2"), last.P50.Format("Jan 2"), len(h), first.At.Local().Format("Jan 2"),
+		added, removed, reestimated)
+}
+
+// WriteTable writes this history as aligned columns, for humans. DRIFT is
+// how far the 50% predicted date moved since the previous snapshot, and
+// SCOPE is the tasks added (+), removed (-) and re-estimated (~) since then.
+func (h ScheduleHistory) WriteTable(w io.Writer) error {
+	if len(h) == 0 {
+		_, err := fmt.Fprintln(w, h.RenderSummary())
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "RECORDED\tTASKS\tESTIMATE\t50%%\t80%%\t95%%\tDRIFT\tSCOPE\n")
+	for i, s := range h {
+		drift, scope := "-", "-"
+		if i > 0 {
+			added, removed, reestimated := s.scopeChange(h[i-1])
+			drift = renderDrift(h[i-1].P50, s.P50)
+			scope = fmt.Sprintf("+%d -%d ~%d", added, removed, reestimated)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.1fh\t%s\t%s\t%s\t%s\t%s\n", s.At.Local().Format("2006-01-02 15:04"), len(s.Tasks), s.Estimated().Hours(),
+			s.P50.Format("Jan 2"), s.P80.Format("Jan 2"), s.P95.Format("Jan 2"), drift, scope)
+	}
+	fmt.Fprintf(tw, "\n%s\n", h.RenderSummary())
+	return tw.Flush()
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleHistory(t *testing.T) {
+	ef, cleanup := newTestEstFile(t)
+	defer cleanup()
+	dir := filepath.Dir(ef.storage.FileName())
+	// Monday 2018-01-01, 10am
+	mon := time.Date(2018, 1, 1, 10, 0, 0, 0, time.Local)
+	estimated := func(estimate time.Duration) *Task {
+		tk := NewTask()
+		tk.task.Estimated = estimate
+		return tk
+	}
+	schedule := func(p50 time.Time) [100]time.Time {
+		var dates [100]time.Time
+		for i := range dates {
+			dates[i] = p50.AddDate(0, 0, i-49)
+		}
+		return dates
+	}
+	a, b, c := estimated(4*time.Hour), estimated(8*time.Hour), estimated(2*time.Hour)
+
+	h, err := ef.ScheduleHistory()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(h))
+	assert.Equal(t, "no schedule history, see 'est schedule'", h.RenderSummary())
+
+	s := NewScheduleSnapshot(schedule(mon.AddDate(0, 0, 10)), tasks{a, b}, mon)
+	assert.Equal(t, mon.AddDate(0, 0, 10), s.P50)
+	assert.Equal(t, mon.AddDate(0, 0, 40), s.P80)
+	assert.Equal(t, mon.AddDate(0, 0, 55), s.P95)
+	assert.Equal(t, 12*time.Hour, s.Estimated())
+	assert.NoError(t, ef.RecordSchedule(s, time.Local))
+	// Same day and tasks, e.g. 'est schedule' run twice.
+	assert.NoError(t, ef.RecordSchedule(NewScheduleSnapshot(schedule(mon.AddDate(0, 0, 11)), tasks{a, b}, mon.Add(time.Hour)), time.Local))
+	h, err = ef.ScheduleHistory()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(h), "same day and tasks replaced")
+	assert.True(t, mon.AddDate(0, 0, 11).Equal(h[0].P50))
+
+	// A week later, a is started, c is added, and b is re-estimated.
+	b.task.Estimated = 16 * time.Hour
+	assert.NoError(t, ef.RecordSchedule(NewScheduleSnapshot(schedule(mon.AddDate(0, 0, 14)), tasks{b, c}, mon.AddDate(0, 0, 7)), time.Local))
+	h, err = ef.ScheduleHistory()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(h))
+	assert.Equal(t, 2, len(h[1].Tasks))
+	assert.Equal(t, b.Name(), h[1].Tasks[0].Name)
+	assert.Equal(t, 18*time.Hour, h[1].Estimated())
+	assert.Equal(t, 1, len(h.Since(mon.AddDate(0, 0, 1))))
+
+	assert.Equal(t, "50% predicted date moved +3d, from Jan 12 to Jan 15, over 2 schedules since Jan 1; 1 tasks added, 1 removed, 1 re-estimated", h.RenderSummary())
+	var buf bytes.Buffer
+	assert.NoError(t, h.WriteTable(&buf))
+	assert.Contains(t, buf.String(), "2018-01-01 11:00  2      12.0h     Jan 12  Feb 11  Feb 26  -      -\n")
+	assert.Contains(t, buf.String(), "2018-01-08 10:00  2      18.0h     Jan 15  Feb 14  Mar 1   +3d    +1 -1 ~1\n")
+
+	// The history is capped, dropping the oldest snapshots.
+	full := scheduleHistoryFile{Snapshots: h}
+	for len(full.Snapshots) < scheduleHistoryMaxSnapshots {
+		full.Snapshots = append(full.Snapshots, h[1])
+	}
+	historyFileName := sidecarFileName(filepath.Join(dir, "estfile.toml"), "schedule-history")
+	assert.NoError(t, writeSidecar(historyFileName, full))
+	assert.NoError(t, ef.RecordSchedule(NewScheduleSnapshot(schedule(mon.AddDate(0, 0, 28)), tasks{c}, mon.AddDate(0, 0, 14)), time.Local))
+	h, err = ef.ScheduleHistory()
+	assert.NoError(t, err)
+	assert.Equal(t, scheduleHistoryMaxSnapshots, len(h))
+	assert.True(t, mon.AddDate(0, 0, 7).Equal(h[0].At), "oldest snapshot dropped")
+	assert.True(t, mon.AddDate(0, 0, 14).Equal(h[len(h)-1].At))
+	fs, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(fs), "only estfile and history, without temporary files")
+}
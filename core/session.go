@@ -0,0 +1,260 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ryanberckmans/est/core/worktimes"
+)
+
+// Session sources, see Session.Source.
+const (
+	SessionSourceAuto     = "auto"     // tracked by auto time tracking while the task was started
+	SessionSourceManual   = "manual"   // logged with 'est log' or --log
+	SessionSourceMigrated = "migrated" // actual time tracked before est recorded sessions, whose times are approximate
+	SessionSourceImported = "imported" // actual time imported from another tool, whose times are approximate
+)
+
+// Session is one interval of time worked on a task. A task's actual time
+// is the sum of its sessions' durations.
+type Session struct {
+	ID       uuid.UUID
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration // time credited to the task, which may be less than End-Start, e.g. excluding non-working hours
+	Share    float64       // fraction of working time between Start and End credited to the task, e.g. 0.5 if two tasks with equal weights were started
+	Source   string        // one of SessionSourceAuto, SessionSourceManual, etc.
+}
+
+// DefaultDuration returns the duration implied by this session's start and
+// end: for auto sessions, the working time between them times Share;
+// otherwise, the time between them.
+func (s Session) DefaultDuration(wt worktimes.WorkTimes) time.Duration {
+	if s.Source == SessionSourceAuto {
+		return time.Duration(float64(wt.DurationBetween(s.Start, s.End)) * s.Share)
+	}
+	return s.End.Sub(s.Start)
+}
+
+func newSession(start, end time.Time, d time.Duration, share float64, source string) Session {
+	return Session{
+		ID:       uuid.New(),
+		Start:    start,
+		End:      end,
+		Duration: d,
+		Share:    share,
+		Source:   source,
+	}
+}
+
+// Sessions returns a copy of this task's sessions, in order of start time.
+func (t *Task) Sessions() []Session {
+	ss := make([]Session, len(t.task.Sessions))
+	copy(ss, t.task.Sessions)
+	return ss
+}
+
+// FindSessionByIDPrefix returns the index of this task's first session to
+// match passed Session.ID prefix. Returns -1 if no session found.
+func (t *Task) FindSessionByIDPrefix(prefix string) int {
+	if prefix == "" {
+		return -1
+	}
+	for i, s := range t.task.Sessions {
+		if strings.HasPrefix(s.ID.String(), prefix) {
+			return i
+		}
+	}
+	return -1
+}
+
+// FindSessionByIDPrefix returns the indices of the first task and session to
+// match passed Session.ID prefix. Returns -1, -1 if no session found.
+func (ts tasks) FindSessionByIDPrefix(prefix string) (int, int) {
+	for i, t := range ts {
+		if j := t.FindSessionByIDPrefix(prefix); j > -1 {
+			return i, j
+		}
+	}
+	return -1, -1
+}
+
+// EditSession sets the start, end and duration of the ith session of this
+// task. Editing a session doesn't affect auto time tracking of this task.
+func (t *Task) EditSession(i int, start, end time.Time, d time.Duration) error {
+	if end.Before(start) {
+		return errors.New("session end must not be before its start")
+	}
+	if d < 0 {
+		return errors.New("session duration cannot be negative")
+	}
+	s := &t.task.Sessions[i]
+	s.Start = start
+	s.End = end
+	s.Duration = d
+	t.task.sortSessions()
+	return nil
+}
+
+// SplitSession splits the ith session of this task into two sessions at the
+// passed time. The session's duration is divided in proportion to the
+// working time on either side of the split, or to the time on either side
+// if the session has no working time.
+func (t *Task) SplitSession(wt worktimes.WorkTimes, i int, at time.Time) error {
+	s := t.task.Sessions[i]
+	if !at.After(s.Start) || !at.Before(s.End) {
+		return fmt.Errorf("session can only be split between its start (%s) and end (%s)", s.Start.Local().Format(sessionTimeLayout), s.End.Local().Format(sessionTimeLayout))
+	}
+	frac := float64(at.Sub(s.Start)) / float64(s.End.Sub(s.Start))
+	if total := wt.DurationBetween(s.Start, s.End); total > 0 {
+		frac = float64(wt.DurationBetween(s.Start, at)) / float64(total)
+	}
+	first := time.Duration(float64(s.Duration) * frac)
+	s2 := newSession(at, s.End, s.Duration-first, s.Share, s.Source)
+	t.task.Sessions[i].End = at
+	t.task.Sessions[i].Duration = first
+	t.task.Sessions = append(t.task.Sessions, s2)
+	t.task.sortSessions()
+	return nil
+}
+
+// RemoveSession removes the ith session of this task, reducing its actual
+// time. Removing a session doesn't affect auto time tracking of this task.
+func (t *Task) RemoveSession(i int) {
+	t.task.Sessions = append(t.task.Sessions[:i], t.task.Sessions[i+1:]...)
+}
+
+// addAutoSession credits this task with passed auto tracked time between
+// start and end. The most recent auto session is extended if it ends at
+// start, on the same day in passed working location, with the same share; so
+// that a task started all day has one session per day, rather than one per
+// invocation of est.
+func (t *Task) addAutoSession(start, end time.Time, d time.Duration, share float64, loc *time.Location) {
+	if n := len(t.task.Sessions); n > 0 {
+		last := &t.task.Sessions[n-1]
+		if last.Source == SessionSourceAuto && last.Share == share && last.End.Equal(start) && worktimes.StartOfDay(last.Start.In(loc)).Equal(worktimes.StartOfDay(start.In(loc))) {
+			last.End = end
+			last.Duration += d
+			return
+		}
+	}
+	t.task.Sessions = append(t.task.Sessions, newSession(start, end, d, share, SessionSourceAuto))
+	t.task.sortSessions()
+}
+
+// autoTrackedBetween returns the time auto tracked on these tasks between
+// from and to, including time which will be tracked on started tasks up to
+// passed now, and the first task with such time. An auto session which
+// partially overlaps from and to counts its share of the working time in
+// the overlap.
+func (ts tasks) autoTrackedBetween(wt worktimes.WorkTimes, from, to, now time.Time) (time.Duration, *Task) {
+	var d time.Duration
+	var first *Task
+	add := func(t *Task, start, end time.Time, share float64) {
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if !end.After(start) {
+			return
+		}
+		if d2 := time.Duration(float64(wt.DurationBetween(start, end)) * share); d2 > 0 {
+			d += d2
+			if first == nil {
+				first = t
+			}
+		}
+	}
+	started := ts.IsStarted()
+	shares := started.shares()
+	for _, t := range ts {
+		for _, s := range t.task.Sessions {
+			if s.Source == SessionSourceAuto {
+				add(t, s.Start, s.End, s.Share)
+			}
+		}
+	}
+	for i, t := range started {
+		// Time not yet tracked is shared by all started tasks, see
+		// autoAddActual(). This is approximate if started tasks were
+		// last tracked at different times.
+		add(t, t.task.ActualUpdatedAt, now, shares[i])
+	}
+	return d, first
+}
+
+// addLegacySession moves this task's legacy accumulated actual time, if
+// any, into one session with the passed source. The session's times are
+// approximate: it ends when time was last tracked, and starts when the
+// task was started, or earlier if that doesn't leave room for the time.
+func (t *task) addLegacySession(source string) {
+	if t.Actual == 0 {
+		return
+	}
+	end := latestTime(t.ActualUpdatedAt, t.DoneAt)
+	start := t.StartedAt
+	if start.IsZero() || start.After(end) || (t.Actual > 0 && end.Sub(start) < t.Actual) {
+		start = end.Add(-t.Actual)
+	}
+	if start.After(end) {
+		// negative actual time, which 'est doctor' reports
+		start = end
+	}
+	s := newSession(start, end, t.Actual, 1, source)
+	// Deterministic ID, so that copies of an estfile migrated separately
+	// have the same session, see Merge().
+	s.ID = uuid.NewSHA1(t.ID, []byte(source))
+	t.Sessions = append(t.Sessions, s)
+	t.Actual = 0
+	t.sortSessions()
+}
+
+func (t *task) sortSessions() {
+	sort.SliceStable(t.Sessions, func(i, j int) bool {
+		return t.Sessions[i].Start.Before(t.Sessions[j].Start)
+	})
+}
+
+// sessionTimeLayout is the layout in which session times are shown to users.
+const sessionTimeLayout = "2006-01-02 15:04"
+
+// RenderSessions returns a user-suitable table of the passed tasks' sessions
+// which end at or after passed time, in order of start time.
+func RenderSessions(ts tasks, since time.Time) string {
+	type row struct {
+		t *Task
+		s Session
+	}
+	var rows []row
+	for _, t := range ts {
+		for _, s := range t.task.Sessions {
+			if !s.End.Before(since) {
+				rows = append(rows, row{t, s})
+			}
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].s.Start.Before(rows[j].s.Start)
+	})
+	rs := make([]string, len(rows)+2) // +1 causes the last element to be empty string, which causes the Join to add an extra newline
+	rs[0] = "ID\tSTART\t\t\tEND\t\t\tDURATION\tSHARE\tSOURCE\t\tTASK"
+	for i, r := range rows {
+		rs[i+1] = fmt.Sprintf("%s\t%s\t%s\t%.2fh\t\t%.2f\t%-8s\t%s %s",
+			r.s.ID.String()[0:5],
+			r.s.Start.Local().Format(sessionTimeLayout),
+			r.s.End.Local().Format(sessionTimeLayout),
+			r.s.Duration.Hours(),
+			r.s.Share,
+			r.s.Source,
+			r.t.ID().String()[0:5],
+			r.t.Name(),
+		)
+	}
+	return strings.Join(rs, "\n")
+}
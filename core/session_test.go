@@ -0,0 +1,58 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ryanberckmans/est/core/worktimes"
+)
+
+func TestAutoSessions(t *testing.T) {
+	wt := worktimes.GetAnonymousWorkTimes()
+	// Monday 2018-01-01, 10am
+	mon := time.Date(2018, 1, 1, 10, 0, 0, 0, time.Local)
+	tk := getStartedTask()
+	tk.task.ActualUpdatedAt = mon
+	ts := tasks{tk}
+
+	autoAddActual(wt, ts, mon.Add(time.Hour))
+	autoAddActual(wt, ts, mon.Add(2*time.Hour))
+	assert.Equal(t, 1, len(tk.Sessions()), "session extended by successive tracking")
+	assert.Equal(t, 2*time.Hour, tk.Actual())
+
+	autoAddActual(wt, ts, mon.AddDate(0, 0, 1).Add(time.Hour))
+	ss := tk.Sessions()
+	assert.Equal(t, 2, len(ss), "one session per day")
+	assert.Equal(t, wt.DurationBetween(mon, mon.AddDate(0, 0, 1).Add(time.Hour)), tk.Actual())
+	assert.Equal(t, mon.AddDate(0, 0, 1).Add(-30*time.Minute), ss[1].Start, "session starts at start of working hours")
+	assert.Equal(t, mon.AddDate(0, 0, 1).Add(time.Hour), tk.task.ActualUpdatedAt)
+
+	actual := tk.Actual()
+	assert.NoError(t, tk.SplitSession(wt, 0, mon.Add(time.Hour)))
+	ss = tk.Sessions()
+	assert.Equal(t, 3, len(ss))
+	assert.Equal(t, time.Hour, ss[0].Duration, "duration divided by working time")
+	assert.Equal(t, actual, tk.Actual(), "split preserves actual")
+	assert.Error(t, tk.SplitSession(wt, 0, mon.Add(-time.Hour)), "split outside of session")
+
+	tk.RemoveSession(tk.FindSessionByIDPrefix(ss[0].ID.String()[0:8]))
+	assert.Equal(t, actual-time.Hour, tk.Actual())
+}
+
+func TestMigrateEstFile(t *testing.T) {
+	now := time.Now()
+	tk := getPausedTask()
+	tk.task.StartedAt = now.Add(-time.Hour)
+	tk.task.ActualUpdatedAt = now
+	tk.task.Actual = 3 * time.Hour
+	ef := estFile{Version: 1, Tasks: []task{tk.task}}
+	migrateEstFile(&ef)
+	assert.Equal(t, 2, ef.Version)
+	tk2 := &Task{task: ef.Tasks[0]}
+	assert.Equal(t, 3*time.Hour, tk2.Actual())
+	assert.Equal(t, time.Duration(0), tk2.task.Actual)
+	assert.Equal(t, SessionSourceMigrated, tk2.Sessions()[0].Source)
+	assert.Equal(t, now.Add(-3*time.Hour), tk2.Sessions()[0].Start, "session long enough to hold actual time")
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ryanberckmans/est/core/worktimes"
+)
+
+// Defaults for estconfig stalemultiple and stalehours, see StaleTasks().
+const (
+	defaultStaleMultiple = 3.0
+	defaultStaleHours    = 16.0
+)
+
+// eventTypePauseAsOf is the event type of a retroactive pause, see PauseAsOf().
+const eventTypePauseAsOf = "pause-as-of"
+
+// StaleTask is a started task which was probably forgotten, see StaleTasks().
+type StaleTask struct {
+	Task         *Task
+	Actual       time.Duration // actual time of the task as of now, including time not yet tracked
+	LastActivity time.Time     // see Task.LastActivity()
+	Idle         time.Duration // working time since LastActivity
+	Reasons      []string      // why the task is stale, for humans
+}
+
+// LastActivity returns the most recent time at which a human worked on this
+// task with est, e.g. started it or logged time on it. Unlike
+// ActualUpdatedAt, auto time tracking isn't activity. Returns zero time if
+// this task was never started.
+func (t *Task) LastActivity() time.Time {
+	latest := latestTime(t.task.StartedAt, t.task.PausedAt, t.task.DoneAt)
+	for _, s := range t.task.Sessions {
+		if s.Source != SessionSourceAuto {
+			latest = latestTime(latest, s.End)
+		}
+	}
+	for _, e := range t.task.Events {
+		latest = latestTime(latest, e.When)
+	}
+	return latest
+}
+
+// StaleTasks returns the started tasks among passed tasks which were
+// probably left started by mistake, because their actual time as of passed
+// now exceeds multiple times their estimate, or because they've had no
+// activity for more than passed hours of working time. Such a task's actual
+// time would skew the estimate accuracy ratios used to predict schedules.
+func StaleTasks(wt worktimes.WorkTimes, ts tasks, now time.Time, multiple, hours float64) []StaleTask {
+	// Track time on copies of started tasks, so that actual time includes
+	// time since time was last tracked, without changing passed tasks.
+	started := ts.IsStarted().IsNotDeleted()
+	copies := make(tasks, len(started))
+	for i, t := range started {
+		t2 := &Task{task: t.task}
+		t2.task.Sessions = t.Sessions()
+		copies[i] = t2
+	}
+	autoAddActual(wt, copies, now)
+
+	var ss []StaleTask
+	for i, t := range started {
+		s := StaleTask{
+			Task:         t,
+			Actual:       copies[i].Actual(),
+			LastActivity: t.LastActivity(),
+		}
+		s.Idle = wt.DurationBetween(s.LastActivity, now)
+		if multiple > 0 && t.Estimated() > 0 && float64(s.Actual) > multiple*float64(t.Estimated()) {
+			s.Reasons = append(s.Reasons, fmt.Sprintf("actual %.1fh is more than %gx estimate %.1fh", s.Actual.Hours(), multiple, t.Estimated().Hours()))
+		}
+		if hours > 0 && s.Idle.Hours() > hours {
+			s.Reasons = append(s.Reasons, fmt.Sprintf("no activity for %.1f working hours, since %s", s.Idle.Hours(), s.LastActivity.Local().Format(sessionTimeLayout)))
+		}
+		if len(s.Reasons) > 0 {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
+
+// RenderStaleTasks returns a user-suitable description of passed stale tasks.
+func RenderStaleTasks(ss []StaleTask) string {
+	var b strings.Builder
+	for _, s := range ss {
+		fmt.Fprintf(&b, "%s %s: %s\n", s.Task.ID().String()[0:5], s.Task.Name(), strings.Join(s.Reasons, "; "))
+	}
+	return b.String()
+}
+
+// PauseAsOf pauses the ith task as of passed time in the past, e.g. its last
+// activity if it was forgotten while started. Time is tracked on started
+// tasks up to passed now, then time auto tracked on the ith task after the
+// passed time is removed. Other started tasks keep their share of time
+// during that period. The removal is recorded as a correction to actual
+// time, see ActualCorrections().
+func (ts tasks) PauseAsOf(wt worktimes.WorkTimes, i int, at, now time.Time) error {
+	t := ts[i]
+	if !t.IsStarted() {
+		return errors.New("cannot pause a task which isn't started")
+	}
+	if at.After(now) {
+		return errors.New("cannot pause a task in the future")
+	}
+	if at.Before(t.task.StartedAt) {
+		return fmt.Errorf("cannot pause a task before it was started at %s", t.task.StartedAt.Local().Format(sessionTimeLayout))
+	}
+	if err := ts.Pause(wt, i, now); err != nil {
+		return err
+	}
+	before := t.Actual()
+	var kept []Session
+	for j := range t.task.Sessions {
+		s := t.task.Sessions[j]
+		if s.Source != SessionSourceAuto || !s.End.After(at) {
+			kept = append(kept, s)
+			continue
+		}
+		if s.Start.Before(at) {
+			// Keep the part of this session before the pause.
+			frac := float64(at.Sub(s.Start)) / float64(s.End.Sub(s.Start))
+			if total := wt.DurationBetween(s.Start, s.End); total > 0 {
+				frac = float64(wt.DurationBetween(s.Start, at)) / float64(total)
+			}
+			s.Duration = time.Duration(float64(s.Duration) * frac)
+			s.End = at
+			kept = append(kept, s)
+		}
+	}
+	t.task.Sessions = kept
+	t.task.PausedAt = at
+	t.task.ActualUpdatedAt = latestTime(t.task.StartedAt, at)
+	t.addActualEvent(eventTypePauseAsOf, fmt.Sprintf("paused as of %s, actual %.2fh -> %.2fh", at.Local().Format(sessionTimeLayout), before.Hours(), t.Actual().Hours()), now)
+	return nil
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ryanberckmans/est/core/worktimes"
+)
+
+func TestStaleTasks(t *testing.T) {
+	wt := worktimes.GetAnonymousWorkTimes()
+	// Monday 2018-01-01, 10am
+	mon := time.Date(2018, 1, 1, 10, 0, 0, 0, time.Local)
+	start := func(estimate time.Duration) *Task {
+		tk := getStartedTask()
+		tk.task.Estimated = estimate
+		tk.task.CreatedAt = mon
+		tk.task.StartedAt = mon
+		tk.task.ActualUpdatedAt = mon
+		return tk
+	}
+	fresh, forgotten, overrun := start(8*time.Hour), start(40*time.Hour), start(time.Hour)
+	ts := tasks{fresh, forgotten, overrun}
+	now := mon.AddDate(0, 0, 3) // Thursday 10am
+	fresh.task.Sessions = []Session{newSession(now.Add(-time.Hour), now.Add(-30*time.Minute), 30*time.Minute, 1, SessionSourceManual)}
+	// e.g. 'est add --start --at <Monday>' on Wednesday; adding isn't working on it
+	forgotten.task.CreatedAt = mon.AddDate(0, 0, 2)
+
+	ss := StaleTasks(wt, ts, now, 3, 16)
+	assert.Equal(t, 2, len(ss))
+	assert.Equal(t, forgotten, ss[0].Task)
+	assert.Equal(t, 1, len(ss[0].Reasons), "forgotten task is idle but within estimate")
+	assert.Equal(t, mon, ss[0].LastActivity)
+	assert.Equal(t, overrun, ss[1].Task)
+	assert.Equal(t, 2, len(ss[1].Reasons), "overrun task is idle and over estimate")
+	assert.Equal(t, 0, len(forgotten.Sessions()), "passed tasks unchanged")
+	assert.Equal(t, 0, len(StaleTasks(wt, ts, now, -1, -1)), "checks disabled")
+
+	// Pause the forgotten task as of its last activity.
+	i := ts.FindByIDPrefix(forgotten.ID().String())
+	assert.NoError(t, ts.PauseAsOf(wt, i, mon.Add(time.Hour), now))
+	assert.True(t, forgotten.IsPaused())
+	assert.Equal(t, mon.Add(time.Hour), forgotten.PausedAt())
+	assert.Equal(t, share(time.Hour, 1.0/3), forgotten.Actual(), "time after pause removed")
+	assert.Equal(t, 1, len(forgotten.ActualCorrections()))
+	assert.True(t, overrun.Actual() > time.Hour, "other started tasks keep their time")
+	assert.Error(t, ts.PauseAsOf(wt, i, mon, now), "not started")
+}
+
+func share(d time.Duration, f float64) time.Duration {
+	return time.Duration(float64(d) * f)
+}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ryanberckmans/est/core/worktimes"
+)
+
+// Biases of estimates, see AccuracyStatsGroup.Bias.
+const (
+	BiasUnderestimate = "underestimate" // tasks tend to take longer than estimated
+	BiasOverestimate  = "overestimate"  // tasks tend to take less time than estimated
+	BiasNone          = "none"          // within 5% on average
+)
+
+// biasThreshold is how far from a perfect estimate the geometric mean
+// accuracy ratio must be for estimates to be biased.
+const biasThreshold = 1.05
+
+// AccuracyStats are statistics of the accuracy of historical estimates, for
+// tracking calibration over time. See NewAccuracyStats().
+type AccuracyStats struct {
+	All   AccuracyStatsGroup  `json:"all"`
+	Weeks []AccuracyStatsWeek `json:"weeks"` // trend by week, oldest first
+	Sizes []AccuracyStatsSize `json:"sizes"` // by size of estimate, smallest first
+}
+
+// AccuracyStatsGroup are statistics of one group of accuracy ratios. Ratios
+// are estimated / actual, so a ratio below 1 is a task which took longer than
+// estimated. Statistics are null if the group is empty.
+type AccuracyStatsGroup struct {
+	Count         int      `json:"count"`
+	Median        *float64 `json:"median_ratio"`
+	GeometricMean *float64 `json:"geometric_mean_ratio"` // typical ratio, such that 2x too high and 2x too low cancel out
+	Within20      *float64 `json:"within_20_percent"`    // fraction of ratios within 20% of a perfect estimate
+	Within50      *float64 `json:"within_50_percent"`    // fraction of ratios within 50% of a perfect estimate
+	Bias          string   `json:"bias,omitempty"`       // one of BiasUnderestimate, etc.
+}
+
+// AccuracyStatsWeek are statistics of accuracy ratios of tasks done in one
+// week.
+type AccuracyStatsWeek struct {
+	Start time.Time `json:"week_start"` // start of Monday of this week
+	AccuracyStatsGroup
+}
+
+// AccuracyStatsSize are statistics of accuracy ratios of estimates of one
+// size.
+type AccuracyStatsSize struct {
+	Size string `json:"size"` // e.g. "1-4h"
+	AccuracyStatsGroup
+}
+
+// accuracyStatsSizes are the sizes of estimates in AccuracyStats.Sizes, each
+// up to but excluding max.
+var accuracyStatsSizes = []struct {
+	name string
+	max  time.Duration
+}{
+	{"<1h", time.Hour},
+	{"1-4h", 4 * time.Hour},
+	{"4-16h", 16 * time.Hour},
+	{">16h", math.MaxInt64},
+}
+
+// NewAccuracyStats returns statistics of passed accuracy ratios, with a
+// trend over passed number of weeks up to and including the week of passed
+// now, in passed working hours' timezone.
+func NewAccuracyStats(wt worktimes.WorkTimes, ars AccuracyRatios, weeks int, now time.Time) AccuracyStats {
+	s := AccuracyStats{All: newAccuracyStatsGroup(ars)}
+
+	today := worktimes.StartOfDay(now.In(wt.Location()))
+	monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	for i := weeks - 1; i >= 0; i-- {
+		start := monday.AddDate(0, 0, -7*i)
+		end := start.AddDate(0, 0, 7)
+		week := filterAccuracyRatios(ars, func(ar *AccuracyRatio) bool {
+			return !ar.time.Before(start) && ar.time.Before(end)
+		})
+		s.Weeks = append(s.Weeks, AccuracyStatsWeek{Start: start, AccuracyStatsGroup: newAccuracyStatsGroup(week)})
+	}
+
+	var min time.Duration
+	for _, size := range accuracyStatsSizes {
+		lo, hi := min, size.max
+		group := filterAccuracyRatios(ars, func(ar *AccuracyRatio) bool {
+			return ar.duration >= lo && ar.duration < hi
+		})
+		s.Sizes = append(s.Sizes, AccuracyStatsSize{Size: size.name, AccuracyStatsGroup: newAccuracyStatsGroup(group)})
+		min = size.max
+	}
+	return s
+}
+
+func newAccuracyStatsGroup(ars AccuracyRatios) AccuracyStatsGroup {
+	g := AccuracyStatsGroup{Count: len(ars)}
+	if len(ars) == 0 {
+		return g
+	}
+	rs := make([]float64, 0, len(ars))
+	var logSum float64
+	var within20, within50 int
+	for _, ar := range ars {
+		rs = append(rs, ar.ratio)
+		logSum += math.Log(ar.ratio)
+		if withinAccuracy(ar.ratio, 0.2) {
+			within20++
+		}
+		if withinAccuracy(ar.ratio, 0.5) {
+			within50++
+		}
+	}
+	sort.Float64s(rs)
+	m := median(rs)
+	geoMean := math.Exp(logSum / float64(len(rs)))
+	w20 := float64(within20) / float64(len(rs))
+	w50 := float64(within50) / float64(len(rs))
+	g.Median, g.GeometricMean, g.Within20, g.Within50 = &m, &geoMean, &w20, &w50
+	switch {
+	case geoMean < 1/biasThreshold:
+		g.Bias = BiasUnderestimate
+	case geoMean > biasThreshold:
+		g.Bias = BiasOverestimate
+	default:
+		g.Bias = BiasNone
+	}
+	return g
+}
+
+// WriteTable writes these statistics as aligned columns, for humans.
+func (s AccuracyStats) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "Estimates:\t%d\n", s.All.Count)
+	if s.All.Count > 0 && s.All.Median != nil {
+		fmt.Fprintf(tw, "Median accuracy ratio:\t%.2f\n", *s.All.Median)
+		fmt.Fprintf(tw, "Geometric mean accuracy ratio:\t%.2f\n", *s.All.GeometricMean)
+		fmt.Fprintf(tw, "Within ±20%%:\t%.0f%%\n", 100**s.All.Within20)
+		fmt.Fprintf(tw, "Within ±50%%:\t%.0f%%\n", 100**s.All.Within50)
+		fmt.Fprintf(tw, "Bias:\t%s\n", renderBias(s.All))
+	}
+	fmt.Fprintf(tw, "\nWEEK\tCOUNT\tMEDIAN\tGEOMEAN\t±20%%\t±50%%\tBIAS\n")
+	for _, wk := range s.Weeks {
+		fmt.Fprintf(tw, "%s\t%s\n", wk.Start.Format("2006-01-02"), renderAccuracyStatsGroupRow(wk.AccuracyStatsGroup))
+	}
+	fmt.Fprintf(tw, "\nSIZE\tCOUNT\tMEDIAN\tGEOMEAN\t±20%%\t±50%%\tBIAS\n")
+	for _, sz := range s.Sizes {
+		fmt.Fprintf(tw, "%s\t%s\n", sz.Size, renderAccuracyStatsGroupRow(sz.AccuracyStatsGroup))
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes these statistics as indented JSON.
+func (s AccuracyStats) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+func renderAccuracyStatsGroupRow(g AccuracyStatsGroup) string {
+	if g.Median == nil {
+		return fmt.Sprintf("%d\t-\t-\t-\t-\t-", g.Count)
+	}
+	return fmt.Sprintf("%d\t%.2f\t%.2f\t%.0f%%\t%.0f%%\t%s", g.Count, *g.Median, *g.GeometricMean, 100**g.Within20, 100**g.Within50, g.Bias)
+}
+
+// renderBias returns a user-suitable description of the bias of passed group.
+func renderBias(g AccuracyStatsGroup) string {
+	switch g.Bias {
+	case BiasUnderestimate:
+		return fmt.Sprintf("underestimate, tasks typically take %.2fx their estimate", 1 / *g.GeometricMean)
+	case BiasOverestimate:
+		return fmt.Sprintf("overestimate, tasks typically take %.0f%% of their estimate", 100 / *g.GeometricMean)
+	}
+	return "none, tasks typically take their estimate"
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ryanberckmans/est/core/worktimes"
+)
+
+func TestAccuracyStats(t *testing.T) {
+	wt := worktimes.GetAnonymousWorkTimes()
+	// Wednesday 2018-01-31
+	now := time.Date(2018, 1, 31, 10, 0, 0, 0, time.Local)
+	ars := AccuracyRatios{
+		{time: now.AddDate(0, 0, -14), duration: 30 * time.Minute, ratio: 1.1},
+		{time: now.AddDate(0, 0, -8), duration: 2 * time.Hour, ratio: 0.5},
+		{time: now.AddDate(0, 0, -1), duration: 2 * time.Hour, ratio: 0.7},
+		{time: now, duration: 20 * time.Hour, ratio: 0.25},
+	}
+	s := NewAccuracyStats(wt, ars, 3, now)
+
+	assert.Equal(t, 4, s.All.Count)
+	assert.InDelta(t, 0.6, *s.All.Median, 1e-9)
+	assert.InDelta(t, 0.5570, *s.All.GeometricMean, 1e-4)
+	assert.Equal(t, 0.25, *s.All.Within20)
+	assert.Equal(t, 0.5, *s.All.Within50)
+	assert.Equal(t, BiasUnderestimate, s.All.Bias)
+
+	assert.Equal(t, 3, len(s.Weeks))
+	assert.Equal(t, time.Date(2018, 1, 15, 0, 0, 0, 0, time.Local), s.Weeks[0].Start)
+	assert.Equal(t, []int{1, 1, 2}, []int{s.Weeks[0].Count, s.Weeks[1].Count, s.Weeks[2].Count})
+	assert.Equal(t, BiasOverestimate, s.Weeks[0].Bias)
+
+	assert.Equal(t, []string{"<1h", "1-4h", "4-16h", ">16h"}, []string{s.Sizes[0].Size, s.Sizes[1].Size, s.Sizes[2].Size, s.Sizes[3].Size})
+	assert.Equal(t, []int{1, 2, 0, 1}, []int{s.Sizes[0].Count, s.Sizes[1].Count, s.Sizes[2].Count, s.Sizes[3].Count})
+	assert.Nil(t, s.Sizes[2].Median, "empty group has no statistics")
+
+	var b bytes.Buffer
+	assert.NoError(t, s.WriteJSON(&b))
+	var m map[string]interface{}
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &m))
+	assert.Equal(t, 4.0, m["all"].(map[string]interface{})["count"])
+	assert.Equal(t, "1-4h", m["sizes"].([]interface{})[1].(map[string]interface{})["size"])
+	b.Reset()
+	assert.NoError(t, s.WriteTable(&b))
+	assert.Contains(t, b.String(), "underestimate, tasks typically take 1.80x their estimate")
+}
@@ -0,0 +1,279 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Storage kinds, see estconfig and NewStorage().
+const (
+	StorageTOML  = "toml"  // the estfile is one TOML file, rewritten on each change
+	StorageJSONL = "jsonl" // the estfile is an append-only log of changes, one JSON object per line
+)
+
+// StorageKinds are the valid storage kinds.
+var StorageKinds = []string{StorageTOML, StorageJSONL}
+
+// Storage is a backend which persists an estfile. An estfile is always
+// stored in one file, and sidecar files (e.g. for undo history) are stored
+// alongside it. Implementations live in core because they persist estFile.
+type Storage interface {
+	// FileName returns the name of the file in which the estfile is stored.
+	FileName() string
+	// Kind returns the kind of this storage, one of StorageKinds.
+	Kind() string
+	// load returns the stored estfile, creating an empty estfile if none exists.
+	load() (estFile, error)
+	// save stores passed estfile, replacing passed prev, which is the stored
+	// estfile as returned by load or save, or an empty estFile if none is
+	// stored. Returns passed estfile as stored, which may be passed as prev
+	// to the next save.
+	save(prev, ef estFile) (estFile, error)
+}
+
+// NewStorage returns storage of the passed kind for the passed file name.
+func NewStorage(kind, fileName string) (Storage, error) {
+	switch kind {
+	case StorageTOML:
+		return tomlStorage{fileName: fileName}, nil
+	case StorageJSONL:
+		return jsonlStorage{fileName: fileName}, nil
+	}
+	return nil, fmt.Errorf("unknown storage '%s', expected one of %s", kind, strings.Join(StorageKinds, ", "))
+}
+
+// storageKindForFileName returns the storage kind implied by the extension
+// of passed file name, or "" if the extension isn't a storage kind.
+func storageKindForFileName(fileName string) string {
+	ext := strings.TrimPrefix(filepath.Ext(fileName), ".")
+	for _, kind := range StorageKinds {
+		if strings.EqualFold(ext, kind) {
+			return kind
+		}
+	}
+	return ""
+}
+
+// tomlStorage stores an estfile as one TOML file. This is the default
+// storage; a TOML estfile is easy to read and edit by hand.
+type tomlStorage struct {
+	fileName string
+}
+
+func (s tomlStorage) FileName() string {
+	return s.fileName
+}
+
+func (s tomlStorage) Kind() string {
+	return StorageTOML
+}
+
+func (s tomlStorage) load() (estFile, error) {
+	if err := createFileWithDefaultContentsIfNotExists(s.fileName, estFileMode, encodeEstFile(fakeEstfile())); err != nil {
+		return estFile{}, fmt.Errorf("couldn't find or create %s: %s", s.fileName, err)
+	}
+	d, err := ioutil.ReadFile(s.fileName)
+	if err != nil {
+		return estFile{}, err
+	}
+	return decodeEstFile(string(d))
+}
+
+func (s tomlStorage) save(prev, ef estFile) (estFile, error) {
+	return ef, ioutil.WriteFile(s.fileName, []byte(encodeEstFile(ef)), estFileMode)
+}
+
+// jsonlStorage stores an estfile as an append-only log. Each line is a
+// jsonlRecord. Saving appends only the tasks which changed, so saves are
+// cheap regardless of the size of the estfile, and the log is a history of
+// changes to tasks. Loading replays the log. Tasks are keyed by ID, so a
+// jsonl estfile can't contain two tasks with one ID.
+//
+// So that loading doesn't slow down as changes accumulate, the log is
+// compacted to the current state of the estfile when it has more than
+// jsonlCompactFactor records per task, see save().
+type jsonlStorage struct {
+	fileName string
+}
+
+// A jsonl log is compacted when it has more than jsonlCompactFactor records
+// per task and at least jsonlCompactMinRecords records, so that compaction
+// happens once per many saves.
+const (
+	jsonlCompactFactor     = 4
+	jsonlCompactMinRecords = 100
+)
+
+// jsonlRecord is one line of a jsonl estfile. Exactly one of Estfile, Task,
+// and Removed is set.
+type jsonlRecord struct {
+	When    time.Time    `json:"when"`
+	Estfile *jsonlHeader `json:"estfile,omitempty"` // replaces estfile-wide fields
+	Task    *task        `json:"task,omitempty"`    // adds or replaces the task with this ID
+	Removed string       `json:"removed,omitempty"` // ID of removed task, e.g. by 'est undo'
+}
+
+// jsonlHeader is the estfile-wide fields of an estfile.
+type jsonlHeader struct {
+	Version                              int
+	FakeHistoricalEstimateAccuracyRatios []float64
+	ArchivedEstimateAccuracyRatios       []archivedAccuracyRatio
+}
+
+func newJSONLHeader(ef estFile) jsonlHeader {
+	h := jsonlHeader{
+		Version:                              ef.Version,
+		FakeHistoricalEstimateAccuracyRatios: ef.FakeHistoricalEstimateAccuracyRatios,
+		ArchivedEstimateAccuracyRatios:       ef.ArchivedEstimateAccuracyRatios,
+	}
+	if len(h.ArchivedEstimateAccuracyRatios) == 0 {
+		h.ArchivedEstimateAccuracyRatios = nil // so that headers compare equal regardless of nil vs. empty
+	}
+	return h
+}
+
+func (s jsonlStorage) FileName() string {
+	return s.fileName
+}
+
+func (s jsonlStorage) Kind() string {
+	return StorageJSONL
+}
+
+func (s jsonlStorage) load() (estFile, error) {
+	if !fileExists(s.fileName) {
+		if err := createFileWithDefaultContentsIfNotExists(s.fileName, estFileMode, ""); err != nil {
+			return estFile{}, fmt.Errorf("couldn't find or create %s: %s", s.fileName, err)
+		}
+		if _, err := s.save(estFile{}, fakeEstfile()); err != nil {
+			return estFile{}, err
+		}
+	}
+	f, err := os.Open(s.fileName)
+	if err != nil {
+		return estFile{}, err
+	}
+	defer func() { _ = f.Close() }()
+
+	ef := estFile{}
+	var ts []*task // tasks in order of first record, nil once removed
+	indexByID := make(map[string]int)
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<24) // tasks with long histories make long lines
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var r jsonlRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			return estFile{}, fmt.Errorf("%s:%d: %s", s.fileName, line, err)
+		}
+		ef.jsonlRecords++
+		switch {
+		case r.Estfile != nil:
+			ef.Version = r.Estfile.Version
+			ef.FakeHistoricalEstimateAccuracyRatios = r.Estfile.FakeHistoricalEstimateAccuracyRatios
+			ef.ArchivedEstimateAccuracyRatios = r.Estfile.ArchivedEstimateAccuracyRatios
+		case r.Task != nil:
+			id := r.Task.ID.String()
+			if i, ok := indexByID[id]; ok {
+				ts[i] = r.Task
+			} else {
+				indexByID[id] = len(ts)
+				ts = append(ts, r.Task)
+			}
+		case r.Removed != "":
+			if i, ok := indexByID[r.Removed]; ok {
+				ts[i] = nil
+				delete(indexByID, r.Removed)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return estFile{}, err
+	}
+	for _, t := range ts {
+		if t != nil {
+			ef.Tasks = append(ef.Tasks, *t)
+		}
+	}
+	migrateEstFile(&ef)
+	return ef, nil
+}
+
+func (s jsonlStorage) save(prev, ef estFile) (estFile, error) {
+	now := time.Now()
+	if prev.jsonlRecords >= jsonlCompactMinRecords && prev.jsonlRecords > jsonlCompactFactor*(len(ef.Tasks)+1) {
+		ef.jsonlRecords = len(ef.Tasks) + 1
+		return ef, s.compact(ef, now)
+	}
+	isEmpty := true
+	if fi, err := os.Stat(s.fileName); err == nil && fi.Size() > 0 {
+		isEmpty = false
+	}
+	var rs []jsonlRecord
+	if h := newJSONLHeader(ef); isEmpty || encodeJSONL(h) != encodeJSONL(newJSONLHeader(prev)) {
+		rs = append(rs, jsonlRecord{When: now, Estfile: &h})
+	}
+	prevByID := make(map[string]task, len(prev.Tasks))
+	for _, t := range prev.Tasks {
+		prevByID[t.ID.String()] = t
+	}
+	for i := range ef.Tasks {
+		t := ef.Tasks[i]
+		t2, ok := prevByID[t.ID.String()]
+		delete(prevByID, t.ID.String())
+		if ok && encodeTask(t) == encodeTask(t2) {
+			continue
+		}
+		rs = append(rs, jsonlRecord{When: now, Task: &t})
+	}
+	for _, t := range prev.Tasks {
+		if _, ok := prevByID[t.ID.String()]; ok {
+			rs = append(rs, jsonlRecord{When: now, Removed: t.ID.String()})
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	for _, r := range rs {
+		buf.WriteString(encodeJSONL(r))
+	}
+	ef.jsonlRecords = prev.jsonlRecords + len(rs)
+	f, err := os.OpenFile(s.fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, estFileMode)
+	if err != nil {
+		return ef, err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		_ = f.Close()
+		return ef, err
+	}
+	return ef, f.Close()
+}
+
+// compact replaces the log with one record for passed estfile's header and
+// one record for each of its tasks, dropping the history of changes.
+func (s jsonlStorage) compact(ef estFile, now time.Time) error {
+	buf := new(bytes.Buffer)
+	h := newJSONLHeader(ef)
+	buf.WriteString(encodeJSONL(jsonlRecord{When: now, Estfile: &h}))
+	for i := range ef.Tasks {
+		buf.WriteString(encodeJSONL(jsonlRecord{When: now, Task: &ef.Tasks[i]}))
+	}
+	return writeFileAtomic(s.fileName, buf.Bytes())
+}
+
+// encodeJSONL returns the passed value encoded as one line of JSON.
+func encodeJSONL(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Errorf("encodeJSONL failed: %s", err))
+	}
+	return string(b) + "\n"
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONLStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "est-storage-test")
+	if err != nil {
+		panic(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	s, err := NewStorage(StorageJSONL, filepath.Join(dir, "estfile.jsonl"))
+	assert.NoError(t, err)
+
+	ef0, err := getEstFile(s)
+	assert.NoError(t, err)
+	ef := toExportedEstfile(ef0)
+	assert.Equal(t, 0, len(ef.Tasks))
+	assert.NotEmpty(t, ef.FakeHistoricalEstimateAccuracyRatios)
+
+	for _, name := range []string{"first", "second", "third"} {
+		tk := NewTask()
+		assert.NoError(t, tk.SetName(name))
+		ef.Tasks = append(ef.Tasks, tk)
+	}
+	assert.NoError(t, ef.Write("est add"))
+	assert.NoError(t, ef.Tasks[0].SetEstimated(time.Hour))
+	assert.NoError(t, ef.Write("est estimate"))
+	ef.Tasks = tasks{ef.Tasks[0], ef.Tasks[2]}
+	assert.NoError(t, ef.Write("est undo"))
+
+	ef2, err := getEstFile(s)
+	assert.NoError(t, err)
+	assert.Equal(t, encodeEstFile(toUnexportedEstfile(ef)), encodeEstFile(ef2), "replayed log equals last saved estfile")
+
+	d, err := ioutil.ReadFile(s.FileName())
+	assert.NoError(t, err)
+	// header, 3 added tasks, 1 estimated task, 1 removed task
+	assert.Equal(t, 6, len(strings.Split(strings.TrimSpace(string(d)), "\n")), "only changes are appended")
+
+	// Converting to TOML and back yields the same estfile.
+	toml, err := NewStorage(StorageTOML, filepath.Join(dir, "estfile.toml"))
+	assert.NoError(t, err)
+	assert.NoError(t, ef.Convert(toml))
+	assert.Error(t, ef.Convert(toml), "can't convert to existing storage")
+	ef3, err := getEstFile(toml)
+	assert.NoError(t, err)
+	assert.Equal(t, encodeEstFile(ef2), encodeEstFile(ef3))
+}
+
+func TestJSONLStorageCompaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "est-storage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	s, err := NewStorage(StorageJSONL, filepath.Join(dir, "estfile.jsonl"))
+	assert.NoError(t, err)
+	ef0, err := getEstFile(s)
+	assert.NoError(t, err)
+	ef := toExportedEstfile(ef0)
+	a, b := NewTask(), NewTask()
+	ef.Tasks = tasks{a, b}
+	assert.NoError(t, ef.Write("est add"))
+
+	// Removing and re-adding a task moves it last, as in the replayed log.
+	ef.Tasks = tasks{b}
+	assert.NoError(t, ef.Write("est undo"))
+	ef.Tasks = tasks{b, a}
+	assert.NoError(t, ef.Write("est redo"))
+	lines := func() int {
+		d, err := ioutil.ReadFile(s.FileName())
+		assert.NoError(t, err)
+		return len(strings.Split(strings.TrimSpace(string(d)), "\n"))
+	}
+	assert.Equal(t, 5, lines())
+
+	for i := 1; i <= jsonlCompactMinRecords; i++ {
+		assert.NoError(t, a.SetEstimated(time.Duration(i)*time.Minute))
+		assert.NoError(t, ef.Write("est estimate"))
+	}
+	assert.True(t, lines() < jsonlCompactMinRecords, "log was compacted")
+	ef2, err := getEstFile(s)
+	assert.NoError(t, err)
+	assert.Equal(t, encodeEstFile(toUnexportedEstfile(ef)), encodeEstFile(ef2), "compacted log equals last saved estfile")
+	assert.Equal(t, b.ID(), toExportedEstfile(ef2).Tasks[0].ID())
+	_, err = ef.Undo(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 99*time.Minute, ef.Tasks[1].Estimated(), "undo across compaction")
+}
+
+// newTestEstFile returns an empty EstFile stored in a new temporary
+// directory, and a func which removes that directory.
+func newTestEstFile(t *testing.T) (EstFile, func()) {
+	dir, err := ioutil.TempDir("", "est-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+	ef, err := getEstFile(tomlStorage{fileName: filepath.Join(dir, "estfile.toml")})
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	return toExportedEstfile(ef), cleanup
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// minSimilarity is the min similarity of a done task's name to a new task's
+// name for the done task to count as similar, see SimilarDoneTasks().
+const minSimilarity = 0.2
+
+// suggestStopWords are words too common in task names to make tasks similar.
+var suggestStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "as": true, "at": true, "by": true, "for": true, "from": true,
+	"in": true, "into": true, "is": true, "it": true, "of": true, "on": true, "or": true, "the": true,
+	"to": true, "with": true,
+}
+
+var suggestTokenRegexp = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// SimilarTask is a done task whose name is similar to a new task's name, see
+// SimilarDoneTasks().
+type SimilarTask struct {
+	Task       *Task
+	Similarity float64 // 0 to 1, where 1 is the same words
+}
+
+// EstimateSuggestion is a suggested estimate for a new task, from the
+// actual time of similar done tasks. See SuggestEstimate().
+type EstimateSuggestion struct {
+	Estimate time.Duration // median actual time of similar tasks
+	Low      time.Duration // least actual time of similar tasks
+	High     time.Duration // most actual time of similar tasks
+	Count    int           // number of similar tasks
+}
+
+// nameTokens returns the words in passed task name which may make it
+// similar to other task names, lowercase, e.g. "Add button #ui" -> ["add",
+// "button", "ui"].
+func nameTokens(name string) []string {
+	var ts []string
+	for _, t := range suggestTokenRegexp.FindAllString(strings.ToLower(name), -1) {
+		if len(t) > 1 && !suggestStopWords[t] {
+			ts = append(ts, t)
+		}
+	}
+	return ts
+}
+
+// SimilarDoneTasks returns up to n done tasks among passed tasks whose names
+// are most similar to passed name, most similar first. Similarity is the
+// cosine similarity of TF-IDF vectors of task names, so that words which
+// are rare among done tasks, e.g. "settings", count for more than common
+// ones, e.g. "fix".
+func SimilarDoneTasks(ts tasks, name string, n int) []SimilarTask {
+	done := ts.IsNotDeleted().IsDone().IsNonZeroActual()
+	docs := make([][]string, len(done))
+	df := map[string]int{}
+	for i, t := range done {
+		docs[i] = nameTokens(t.Name())
+		seen := map[string]bool{}
+		for _, w := range docs[i] {
+			if !seen[w] {
+				seen[w] = true
+				df[w]++
+			}
+		}
+	}
+	vector := func(words []string) map[string]float64 {
+		v := map[string]float64{}
+		for _, w := range words {
+			v[w]++
+		}
+		for w := range v {
+			// smoothed IDF, so that words in no done task still count
+			v[w] *= math.Log(float64(len(done)+1)/float64(df[w]+1)) + 1
+		}
+		return v
+	}
+	norm := func(v map[string]float64) float64 {
+		var sum float64
+		for _, x := range v {
+			sum += x * x
+		}
+		return math.Sqrt(sum)
+	}
+
+	q := vector(nameTokens(name))
+	qNorm := norm(q)
+	if qNorm == 0 {
+		return nil
+	}
+	var ss []SimilarTask
+	for i, t := range done {
+		v := vector(docs[i])
+		vNorm := norm(v)
+		if vNorm == 0 {
+			continue
+		}
+		var dot float64
+		for w, x := range q {
+			dot += x * v[w]
+		}
+		if sim := dot / (qNorm * vNorm); sim >= minSimilarity {
+			ss = append(ss, SimilarTask{Task: t, Similarity: sim})
+		}
+	}
+	sort.SliceStable(ss, func(i, j int) bool {
+		return ss[i].Similarity > ss[j].Similarity
+	})
+	if len(ss) > n {
+		ss = ss[:n]
+	}
+	return ss
+}
+
+// SuggestEstimate returns a suggested estimate from the actual time of
+// passed similar tasks. Returns false if there are no similar tasks.
+func SuggestEstimate(ss []SimilarTask) (EstimateSuggestion, bool) {
+	if len(ss) == 0 {
+		return EstimateSuggestion{}, false
+	}
+	actuals := make([]float64, len(ss))
+	for i, s := range ss {
+		actuals[i] = float64(s.Task.Actual())
+	}
+	sort.Float64s(actuals)
+	return EstimateSuggestion{
+		Estimate: time.Duration(median(actuals)),
+		Low:      time.Duration(actuals[0]),
+		High:     time.Duration(actuals[len(actuals)-1]),
+		Count:    len(ss),
+	}, true
+}
+
+// Render returns a user-suitable one line summary of this suggestion.
+func (s EstimateSuggestion) Render() string {
+	return fmt.Sprintf("suggested estimate: %.1fh (range %.1fh-%.1fh), from actual time of %d similar done tasks", s.Estimate.Hours(), s.Low.Hours(), s.High.Hours(), s.Count)
+}
+
+// RenderSimilarTasks returns a user-suitable table of passed similar tasks.
+func RenderSimilarTasks(ss []SimilarTask) string {
+	rs := make([]string, len(ss)+2) // +1 causes the last element to be empty string, which causes the Join to add an extra newline
+	rs[0] = "SIMILARITY\tESTIMATE\tACTUAL\t\tID\tNAME"
+	for i, s := range ss {
+		rs[i+1] = fmt.Sprintf("%.2f\t\t%.1fh\t\t%.1fh\t\t%s\t%s", s.Similarity, s.Task.Estimated().Hours(), s.Task.Actual().Hours(), s.Task.ID().String()[0:5], s.Task.Name())
+	}
+	return strings.Join(rs, "\n")
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimilarDoneTasks(t *testing.T) {
+	done := func(name string, estimate, actual time.Duration) *Task {
+		tk := getStartedTask()
+		tk.task.Name = name
+		tk.task.Estimated = estimate
+		tk.task.IsDone = true
+		tk.task.Sessions = []Session{newSession(time.Now().Add(-actual), time.Now(), actual, 1, SessionSourceManual)}
+		return tk
+	}
+	profile := done("add button to profile page", 2*time.Hour, 3*time.Hour)
+	settings := done("Settings page: add dark mode toggle", 4*time.Hour, 6*time.Hour)
+	login := done("fix login bug #auth", time.Hour, 2*time.Hour)
+	notDone := getStartedTask()
+	notDone.task.Name = "add button to settings page"
+	ts := tasks{profile, settings, login, notDone}
+
+	assert.Equal(t, []string{"settings", "page", "add", "dark", "mode", "toggle"}, nameTokens(settings.Name()))
+
+	ss := SimilarDoneTasks(ts, "add button to settings page", 5)
+	assert.Equal(t, 2, len(ss), "unrelated and not done tasks excluded")
+	assert.Equal(t, profile, ss[0].Task)
+	assert.Equal(t, settings, ss[1].Task)
+	assert.True(t, ss[0].Similarity > ss[1].Similarity)
+	assert.Equal(t, 1, len(SimilarDoneTasks(ts, "add button to settings page", 1)))
+	assert.Equal(t, 0, len(SimilarDoneTasks(ts, "the", 5)), "only stop words")
+
+	s, ok := SuggestEstimate(ss)
+	assert.True(t, ok)
+	assert.Equal(t, EstimateSuggestion{Estimate: 4*time.Hour + 30*time.Minute, Low: 3 * time.Hour, High: 6 * time.Hour, Count: 2}, s)
+	_, ok = SuggestEstimate(nil)
+	assert.False(t, ok)
+}
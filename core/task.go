@@ -3,9 +3,12 @@ package core
 import (
 	"errors"
 	"fmt"
+	"math"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/ryanberckmans/est/core/worktimes"
@@ -58,6 +61,20 @@ func (t *Task) Name() string {
 
 const taskNameMaxLen = 120
 
+// truncateName returns the longest prefix of passed task name within
+// taskNameMaxLen bytes, cut on a rune boundary so a multi-byte character
+// isn't split.
+func truncateName(n string) string {
+	if len(n) <= taskNameMaxLen {
+		return n
+	}
+	i := taskNameMaxLen
+	for i > 0 && !utf8.RuneStart(n[i]) {
+		i--
+	}
+	return n[:i]
+}
+
 // SetName sets this task's name.
 func (t *Task) SetName(n string) error {
 	n2 := strings.TrimSpace(n)
@@ -71,6 +88,30 @@ func (t *Task) SetName(n string) error {
 	return nil
 }
 
+var tagRegexp = regexp.MustCompile(`(?:^|\s)#([\w-]+)`)
+
+// Tags returns this task's tags. Tags are words in the task name prefixed
+// with "#", e.g. "fix login bug #auth" has tag "auth". Tags are lowercase.
+func (t *Task) Tags() []string {
+	var tags []string
+	for _, m := range tagRegexp.FindAllStringSubmatch(t.task.Name, -1) {
+		tags = append(tags, strings.ToLower(m[1]))
+	}
+	return tags
+}
+
+// HasTag returns true iff this task has the passed tag, ignoring case
+// and any leading "#".
+func (t *Task) HasTag(tag string) bool {
+	tag2 := strings.ToLower(strings.TrimPrefix(tag, "#"))
+	for _, t2 := range t.Tags() {
+		if t2 == tag2 {
+			return true
+		}
+	}
+	return false
+}
+
 // IsEstimated returns true iff this task has a non-zero estimated duration.
 func (t *Task) IsEstimated() bool {
 	return t.task.Estimated != 0
@@ -127,6 +168,21 @@ func (t *Task) Undelete() error {
 	return nil
 }
 
+// CheckTime returns an error if passed time, e.g. a time in the past at which
+// to start or finish this task, is before this task's most recent activity.
+// Such a time would put this task's history out of order, and time already
+// tracked on this task can't be untracked.
+func (t *Task) CheckTime(at time.Time) error {
+	latest := latestTime(t.task.StartedAt, t.task.PausedAt, t.task.DoneAt, t.task.ActualUpdatedAt)
+	for _, s := range t.task.Sessions {
+		latest = latestTime(latest, s.End)
+	}
+	if at.Before(latest) {
+		return fmt.Errorf("time %s is before this task's most recent activity at %s", at.Local().Format(sessionTimeLayout), latest.Local().Format(sessionTimeLayout))
+	}
+	return nil
+}
+
 // Estimated returns the estimated duration for this task.
 func (t *Task) Estimated() time.Duration {
 	return t.task.Estimated
@@ -142,23 +198,243 @@ func (t *Task) SetEstimated(d time.Duration) error {
 	return nil
 }
 
-// Actual returns the actual duration elapsed for this task.
+// DefaultWeight is the focus weight of a task unless set otherwise.
+const DefaultWeight = 1.0
+
+// Weight returns this task's focus weight. While multiple tasks are started,
+// auto tracked time is shared among them in proportion to their weights.
+func (t *Task) Weight() float64 {
+	if t.task.Weight == 0 {
+		return DefaultWeight
+	}
+	return t.task.Weight
+}
+
+// SetWeight sets the ith task's focus weight, e.g. 0.2 for a task which
+// needs little attention alongside a main task. Time is first tracked on
+// started tasks up to passed now, so that the new weight applies from now.
+func (ts tasks) SetWeight(wt worktimes.WorkTimes, i int, w float64, now time.Time) error {
+	if w <= 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+		return errors.New("weight must be a finite number greater than zero")
+	}
+	if ts[i].IsStarted() {
+		autoAddActual(wt, ts.IsStarted().IsNotDeleted(), now) // IsNotDeleted is sanity because we expect started tasks to never be deleted
+	}
+	ts[i].task.Weight = w
+	if w == DefaultWeight {
+		ts[i].task.Weight = 0
+	}
+	return nil
+}
+
+// shares returns each of these tasks' share of time, in proportion to
+// their weights.
+func (ts tasks) shares() []float64 {
+	var sum float64
+	for _, t := range ts {
+		sum += t.Weight()
+	}
+	ss := make([]float64, len(ts))
+	for i, t := range ts {
+		ss[i] = t.Weight() / sum
+	}
+	return ss
+}
+
+// Share returns this task's share of auto tracked time among passed
+// started tasks, in proportion to their weights. Returns 0 if this task
+// isn't one of the passed tasks.
+func (t *Task) Share(started []*Task) float64 {
+	ts := tasks(started)
+	for i, s := range ts.shares() {
+		if ts[i] == t {
+			return s
+		}
+	}
+	return 0
+}
+
+// Actual returns the actual duration elapsed for this task, which is the
+// sum of its sessions' durations.
 func (t *Task) Actual() time.Duration {
-	return t.task.Actual
+	var d time.Duration
+	for _, s := range t.task.Sessions {
+		d += s.Duration
+	}
+	return d
 }
 
-// AddActual logs actual time spent against this task. Most
-// tasks should use auto time tracking. AddActual() provides
-// an escape hatch for auto time tracking edge cases.
+// AddActual logs actual time spent against this task as a manual session
+// ending at passed time. Most tasks should use auto time tracking.
+// AddActual() provides an escape hatch for auto time tracking edge cases.
 func (t *Task) AddActual(d time.Duration, now time.Time) error {
 	if t.IsNeverStarted() {
 		return errors.New("cannot add actual time to a task which has never been started")
 	}
-	t.task.Actual += d
+	if d < 0 {
+		return errors.New("cannot add negative actual time")
+	}
+	t.task.Sessions = append(t.task.Sessions, newSession(now.Add(-d), now, d, 1, SessionSourceManual))
+	t.task.sortSessions()
 	t.task.ActualUpdatedAt = now
 	return nil
 }
 
+// LogActual logs actual time spent against the ith task as a manual session
+// from passed time to passed time, e.g. to log time worked on a past
+// weekend. The duration may be less than the session, e.g. 3h worked on a
+// past day. Returns an error if the logged time doesn't fit alongside time
+// auto tracked on other tasks during the session, which would count the same
+// time twice. Unlike AddActual(), LogActual() doesn't affect auto time
+// tracking of the task, because the session may be long in the past.
+func (ts tasks) LogActual(wt worktimes.WorkTimes, i int, from, to time.Time, d time.Duration, now time.Time) error {
+	t := ts[i]
+	if t.IsNeverStarted() {
+		return errors.New("cannot add actual time to a task which has never been started")
+	}
+	if d < 0 {
+		return errors.New("cannot add negative actual time")
+	}
+	if !to.After(from) {
+		return errors.New("logged time must end after it starts")
+	}
+	if to.After(now) {
+		return errors.New("cannot log time in the future")
+	}
+	if d > to.Sub(from) {
+		return fmt.Errorf("cannot log %.1fh between %s and %s, which is only %.1fh", d.Hours(), from.Local().Format(sessionTimeLayout), to.Local().Format(sessionTimeLayout), to.Sub(from).Hours())
+	}
+	var others tasks
+	for _, t2 := range ts.IsNotDeleted() {
+		if t2 != t {
+			others = append(others, t2)
+		}
+	}
+	if auto, t2 := others.autoTrackedBetween(wt, from, to, now); auto > 0 && d+auto > to.Sub(from) {
+		return fmt.Errorf("cannot log %.1fh between %s and %s, which overlaps %.1fh auto tracked on tasks including %s %s", d.Hours(), from.Local().Format(sessionTimeLayout), to.Local().Format(sessionTimeLayout), auto.Hours(), t2.ID().String()[0:5], t2.Name())
+	}
+	t.task.Sessions = append(t.task.Sessions, newSession(from, to, d, 1, SessionSourceManual))
+	t.task.sortSessions()
+	return nil
+}
+
+// LogActualOnDay logs actual time spent against the ith task on the day of
+// passed time, see LogActual(). On a workday, the logged time must fit in
+// that day's working hours alongside time auto tracked on other tasks, since
+// auto time tracking only tracks working hours. On other days, it may be
+// logged anytime during the day.
+func (ts tasks) LogActualOnDay(wt worktimes.WorkTimes, i int, day time.Time, d time.Duration, now time.Time) error {
+	from := worktimes.StartOfDay(day.In(wt.Location()))
+	to := worktimes.StartOfDay(from.AddDate(0, 0, 1))
+	if wts := wt.GetWorkTimesOnDay(from); len(wts) > 0 {
+		from, to = wts[0], wts[len(wts)-1]
+	}
+	if to.After(now) {
+		to = now
+	}
+	return ts.LogActual(wt, i, from, to, d, now)
+}
+
+// Event types of corrections to actual time, see SubtractActual() and
+// SetActual(). Each correction is recorded as an event on the task.
+const (
+	eventTypeSubtractActual = "subtract-actual"
+	eventTypeSetActual      = "set-actual"
+)
+
+// SubtractActual removes passed duration from the ith task's actual time,
+// e.g. because auto time tracking over-credited the task during a meeting.
+// Time is removed from the task's most recent sessions first, after
+// tracking time on started tasks up to passed now. Returns an error if
+// actual time would become negative.
+func (ts tasks) SubtractActual(wt worktimes.WorkTimes, i int, d time.Duration, now time.Time) error {
+	if err := ts.correctActual(wt, i, d, now); err != nil {
+		return err
+	}
+	t := ts[i]
+	if d > t.Actual() {
+		return fmt.Errorf("cannot subtract %.1fh from actual %.1fh, actual time can't be negative", d.Hours(), t.Actual().Hours())
+	}
+	t.subtractActual(d, eventTypeSubtractActual, now)
+	return nil
+}
+
+// SetActual sets the ith task's actual time to passed duration, after
+// tracking time on started tasks up to passed now. Time is removed from the
+// task's most recent sessions first, or added as a manual session ending
+// now. Use SetActual() to correct a task's actual time; most tasks should
+// use auto time tracking.
+func (ts tasks) SetActual(wt worktimes.WorkTimes, i int, d time.Duration, now time.Time) error {
+	if err := ts.correctActual(wt, i, d, now); err != nil {
+		return err
+	}
+	t := ts[i]
+	if d < t.Actual() {
+		t.subtractActual(t.Actual()-d, eventTypeSetActual, now)
+		return nil
+	}
+	if d > t.Actual() {
+		add := d - t.Actual()
+		t.addActualEvent(eventTypeSetActual, fmt.Sprintf("actual %.2fh -> %.2fh, added %.2fh", t.Actual().Hours(), d.Hours(), add.Hours()), now)
+		t.task.Sessions = append(t.task.Sessions, newSession(now.Add(-add), now, add, 1, SessionSourceManual))
+		t.task.sortSessions()
+	}
+	return nil
+}
+
+// correctActual validates a correction of the ith task's actual time and
+// tracks time on started tasks up to passed now, so that the correction
+// applies to the task's actual time as of now.
+func (ts tasks) correctActual(wt worktimes.WorkTimes, i int, d time.Duration, now time.Time) error {
+	if ts[i].IsNeverStarted() {
+		return errors.New("cannot correct actual time of a task which has never been started")
+	}
+	if d < 0 {
+		return errors.New("actual time can't be negative")
+	}
+	autoAddActual(wt, ts.IsStarted().IsNotDeleted(), now) // IsNotDeleted is sanity because we expect started tasks to never be deleted
+	return nil
+}
+
+// subtractActual removes passed duration from this task's most recent
+// sessions, recording an event of passed type. Sessions reduced to zero
+// duration are kept, so that the event's session IDs remain meaningful.
+func (t *Task) subtractActual(d time.Duration, eventType string, now time.Time) {
+	before := t.Actual()
+	var changed []string
+	remaining := d
+	for j := len(t.task.Sessions) - 1; j >= 0 && remaining > 0; j-- {
+		s := &t.task.Sessions[j]
+		sub := s.Duration
+		if sub > remaining {
+			sub = remaining
+		}
+		if sub == 0 {
+			continue
+		}
+		s.Duration -= sub
+		remaining -= sub
+		changed = append(changed, fmt.Sprintf("%s -%.2fh", s.ID.String()[0:5], sub.Hours()))
+	}
+	t.addActualEvent(eventType, fmt.Sprintf("actual %.2fh -> %.2fh, subtracted from sessions %s", before.Hours(), t.Actual().Hours(), strings.Join(changed, ", ")), now)
+}
+
+func (t *Task) addActualEvent(eventType, msg string, now time.Time) {
+	t.task.Events = append(t.task.Events, event{When: now, Type: eventType, Msg: msg})
+}
+
+// ActualCorrections returns a user-suitable description of each correction
+// to this task's actual time, in order of time, e.g. by SubtractActual().
+func (t *Task) ActualCorrections() []string {
+	var cs []string
+	for _, e := range t.task.Events {
+		if e.Type == eventTypeSubtractActual || e.Type == eventTypeSetActual || e.Type == eventTypePauseAsOf {
+			cs = append(cs, fmt.Sprintf("%s %s: %s", e.When.Local().Format(sessionTimeLayout), e.Type, e.Msg))
+		}
+	}
+	return cs
+}
+
 // EstimateAccuracyRatio returns a ratio of estimate / actual hours for a done task.
 // I.e. 1.0 is perfect estimate, 2.0 means task was twice as fast, 0.5 task twice as long.
 func (t *Task) EstimateAccuracyRatio() AccuracyRatio {
@@ -166,6 +442,7 @@ func (t *Task) EstimateAccuracyRatio() AccuracyRatio {
 		time:     t.DoneAt(),
 		duration: t.Estimated(),
 		ratio:    t.estimateAccuracyRatio(),
+		tags:     t.Tags(),
 	}
 }
 
@@ -209,6 +486,11 @@ func (t *Task) DeletedAt() time.Time {
 	return t.task.DeletedAt
 }
 
+// latestActivity returns the most recent time at which anything happened to this task.
+func (t *Task) latestActivity() time.Time {
+	return latestTime(t.task.CreatedAt, t.task.EstimatedAt, t.task.StartedAt, t.task.PausedAt, t.task.DoneAt, t.task.DeletedAt, t.task.ActualUpdatedAt)
+}
+
 // Return this task's status and date of that status.
 func (t *Task) status() (taskStatus, time.Time) {
 	switch {
@@ -241,9 +523,27 @@ const (
 	taskStatusUnestimated
 )
 
+// String returns a user-suitable name for this status.
+func (s taskStatus) String() string {
+	switch s {
+	case taskStatusDeleted:
+		return "deleted"
+	case taskStatusDone:
+		return "done"
+	case taskStatusEstimated:
+		return "estimated"
+	case taskStatusPaused:
+		return "paused"
+	case taskStatusStarted:
+		return "started"
+	}
+	return "unestimated"
+}
+
 // RenderYesterdayTasks returns a user-suitable summary of task activity on
 // first business day prior to now.
 func RenderYesterdayTasks(wt worktimes.WorkTimes, ts tasks, now time.Time) string {
+	now = now.In(wt.Location()) // days are in the timezone of working hours
 	for {
 		// Find previous business day by searching for first day in past
 		// with some worktimes. Will never terminate if wt has no worktimes.
@@ -274,7 +574,7 @@ func RenderYesterdayTasks(wt worktimes.WorkTimes, ts tasks, now time.Time) strin
 func RenderTaskOneLineSummary(t *Task, includeHeaders bool) string {
 	var status string
 	statusCode, statusTime := t.status()
-	_, month, day := statusTime.Date()
+	_, month, day := statusTime.Local().Date()
 	switch statusCode {
 	case taskStatusDeleted:
 		// right padding is to align table because "deleted" is a shorter word
@@ -289,10 +589,10 @@ func RenderTaskOneLineSummary(t *Task, includeHeaders bool) string {
 		}
 	case taskStatusStarted:
 		// right padding is to align table because "started" is a shorter word
-		status = fmt.Sprintf("%sstarted%s on %d/%d   ", ansiBold+ansiBoldYellow, ansiReset, month, day)
+		status = fmt.Sprintf("%sstarted%s on %d/%d%s", ansiBold+ansiBoldYellow, ansiReset, month, day, renderWeight(t))
 	case taskStatusPaused:
 		// right padding is to align table because "paused" is a shorter word
-		status = fmt.Sprintf("%spaused%s on %d/%d   ", ansiBold+ansiBoldMagenta, ansiReset, month, day)
+		status = fmt.Sprintf("%spaused%s on %d/%d%s", ansiBold+ansiBoldMagenta, ansiReset, month, day, renderWeight(t))
 	case taskStatusEstimated:
 		status = fmt.Sprintf("estimated on %d/%d", month, day)
 	default:
@@ -311,6 +611,15 @@ func RenderTaskOneLineSummary(t *Task, includeHeaders bool) string {
 	)
 }
 
+// renderWeight returns this task's weight for RenderTaskOneLineSummary, or
+// padding if the task has the default weight.
+func renderWeight(t *Task) string {
+	if t.Weight() == DefaultWeight {
+		return "   "
+	}
+	return fmt.Sprintf(" w=%g", t.Weight())
+}
+
 // task is the unit of estimation for est. Users estimate and do
 // tasks, and then est predicts future tasks' delivery schedule.
 // A task is the same thing as a story, feature, bug, etc.
@@ -319,11 +628,13 @@ type task struct {
 	Name            string
 	Events          []event       // event log to show history to humans  TODO generate event log
 	Estimated       time.Duration // estimated duration for this task (as estimated by a human)
-	Actual          time.Duration // actual duration spent on this task
+	Sessions        []Session     `toml:",omitempty"`                  // intervals of time worked on this task, in order of start time; actual time is the sum of their durations
+	Actual          time.Duration `toml:",omitzero" json:",omitempty"` // legacy actual duration spent on this task, moved into Sessions when an estfile is loaded, see migrateEstFile()
 	ActualUpdatedAt time.Time     // ActualUpdatedAt is last time this task had time logged. This task was never started iff ActualUpdatedAt is zero.
 	IsPaused        bool          // if ActualUpdatedAt is zero or IsDone is true, IsPaused is undefined. Otherwise, this task is paused iff IsPaused.
 	IsDone          bool          // if ActualUpdatedAt is zero, IsDone is undefined. Otherwise, this task is done if IsDone else this task is started.
 	IsDeleted       bool          // this task is deleted iff IsDeleted; orthogonal to other task state.
+	Weight          float64       `toml:",omitzero" json:",omitempty"` // focus weight of this task while started, relative to other started tasks; zero means DefaultWeight, see autoAddActual()
 
 	// These times aren't needed for tasks to work properly; they exist to
 	// show to humans.
@@ -346,8 +657,7 @@ func newTask() task {
 func toExportedTasks(ts []task) tasks {
 	ts2 := make(tasks, len(ts))
 	for i := range ts {
-		// WARNING there is still shared memory allocation between ts2[i] and ts[i], e.g. []event
-		ts2[i] = &Task{task: ts[i]}
+		ts2[i] = &Task{task: copyTask(ts[i])}
 	}
 	return ts2
 }
@@ -355,12 +665,24 @@ func toExportedTasks(ts []task) tasks {
 func toUnexportedTasks(ts tasks) []task {
 	ts2 := make([]task, len(ts))
 	for i := range ts {
-		// WARNING there is still shared memory allocation between ts[i] and ts[i], e.g. []event
-		ts2[i] = ts[i].task
+		ts2[i] = copyTask(ts[i].task)
 	}
 	return ts2
 }
 
+// copyTask returns a copy of passed task which shares no memory with it, so
+// that e.g. an EstFile's snapshot of its loaded estfile isn't changed by
+// changes to its tasks.
+func copyTask(t task) task {
+	if t.Events != nil {
+		t.Events = append(make([]event, 0, len(t.Events)), t.Events...)
+	}
+	if t.Sessions != nil {
+		t.Sessions = append(make([]Session, 0, len(t.Sessions)), t.Sessions...)
+	}
+	return t
+}
+
 // Start the ith task of tasks. When a task transitions to or from started,
 // auto time tracking is updated for all started tasks. Auto time tracking is
 // relative to a set of tasks, so that multiple tasks in progress share the
@@ -466,9 +788,10 @@ func autoAddActual(wt worktimes.WorkTimes, ts tasks, end time.Time) {
 
 		// ts2 is now the tasks which have lowest lastUpdatedAt and lastUpdatedAt
 		// < end. We will auto track shared passage of actual time for these tasks.
-		// Each task will get time at a rate of 1/len(ts2) vs. real time. To properly
-		// share the passage of time, we tick a cohort of tasks with same start time
-		// to the same end time. The start time here is `lowest`. The end time here
+		// Each task will get time at a rate of its weight / sum of ts2's weights
+		// vs. real time, i.e. 1/len(ts2) if all tasks have the default weight. To
+		// properly share the passage of time, we tick a cohort of tasks with same
+		// start time to the same end time. The start time here is `lowest`. The end time here
 		// is the lowest time in ts which is after `lowest`, or `end` if none exists.
 
 		var nextEnd time.Time
@@ -489,14 +812,31 @@ func autoAddActual(wt worktimes.WorkTimes, ts tasks, end time.Time) {
 		// We'll now tick ts2 in shared passage of time. ts2's start time is
 		// the same and lowest of ts. nextEnd is the next lowest time after ts2.
 
-		autoActual := wt.DurationBetween(lowest, nextEnd) // auto time tracking includes workin hours only, otherwise weekends, sleep, etc., would count as time on task.
-		autoActualShared := autoActual / time.Duration(len(ts2))
-		// fmt.Printf("count=%d lowest=%v nextEnd=%v autoActual=%v autoActualShared=%v end=%v\n", len(ts2), lowest, nextEnd, autoActual, autoActualShared, end)
-		for i := range ts2 {
-			err := ts2[i].AddActual(autoActualShared, nextEnd)
-			if err != nil {
-				panic(err)
+		// Time is tracked in at most one session per day, bounded by that
+		// day's working hours, so that sessions show when a task was worked on.
+		// Each task's share of time is in proportion to its weight.
+		shares := ts2.shares()
+		for dayStart := lowest.In(wt.Location()); dayStart.Before(nextEnd); {
+			dayEnd := worktimes.StartOfDay(dayStart.AddDate(0, 0, 1))
+			if dayEnd.After(nextEnd) {
+				dayEnd = nextEnd
+			}
+			autoActual := wt.DurationBetween(dayStart, dayEnd) // auto time tracking includes workin hours only, otherwise weekends, sleep, etc., would count as time on task.
+			// fmt.Printf("count=%d dayStart=%v dayEnd=%v autoActual=%v end=%v\n", len(ts2), dayStart, dayEnd, autoActual, end)
+			if autoActual > 0 {
+				wts := wt.GetWorkTimesOnDay(dayStart)
+				sessionStart, sessionEnd := latestTime(dayStart, wts[0]), dayEnd
+				if wts[len(wts)-1].Before(sessionEnd) {
+					sessionEnd = wts[len(wts)-1]
+				}
+				for i := range ts2 {
+					ts2[i].addAutoSession(sessionStart, sessionEnd, time.Duration(float64(autoActual)*shares[i]), shares[i], wt.Location())
+				}
 			}
+			dayStart = dayEnd
+		}
+		for i := range ts2 {
+			ts2[i].task.ActualUpdatedAt = nextEnd
 		}
 	}
 }
@@ -518,6 +858,20 @@ func (ts tasks) IsNotDeleted() tasks {
 	})
 }
 
+func (ts tasks) HasTag(tag string) tasks {
+	return filterTasks(ts, func(t *Task) bool {
+		return t.HasTag(tag)
+	})
+}
+
+// ActiveSince returns tasks with any activity, such as being created or
+// marked done, at or after passed time.
+func (ts tasks) ActiveSince(since time.Time) tasks {
+	return filterTasks(ts, func(t *Task) bool {
+		return !t.latestActivity().Before(since)
+	})
+}
+
 func (ts tasks) IsDone() tasks {
 	return filterTasks(ts, func(t *Task) bool {
 		return t.IsDone()
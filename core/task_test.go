@@ -1,6 +1,7 @@
 package core
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -80,3 +81,148 @@ func TestPause(t *testing.T) {
 		// ts[0].IsPaused is still true because IsDeleted is orthogonal state
 	})
 }
+
+func TestTags(t *testing.T) {
+	tcs := []struct {
+		name     string
+		taskName string
+		expected []string
+	}{
+		{"no tags", "fix the bug", nil},
+		{"one tag", "fix the bug #auth", []string{"auth"}},
+		{"tags are lowercase", "#Auth fix the bug #UI-v2", []string{"auth", "ui-v2"}},
+		{"hash inside word isn't a tag", "fix bug in c#", nil},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			tk := NewTask()
+			assert.NoError(t, tk.SetName(tc.taskName))
+			assert.Equal(t, tc.expected, tk.Tags())
+		})
+	}
+	tk := NewTask()
+	assert.NoError(t, tk.SetName("fix the bug #auth"))
+	assert.True(t, tk.HasTag("#AUTH"))
+	assert.False(t, tk.HasTag("ui"))
+}
+
+func TestLogActual(t *testing.T) {
+	wt := worktimes.GetAnonymousWorkTimes()
+	// Monday 2018-01-01
+	mon := time.Date(2018, 1, 1, 0, 0, 0, 0, time.Local)
+	now := mon.AddDate(0, 0, 7)
+	sat := mon.AddDate(0, 0, 5)
+
+	t.Run("log time on a past day", func(t *testing.T) {
+		tk := getPausedTask()
+		ts := tasks{tk}
+		cursor := tk.task.ActualUpdatedAt
+		assert.NoError(t, ts.LogActual(wt, 0, sat, sat.AddDate(0, 0, 1), 3*time.Hour, now))
+		assert.Equal(t, 3*time.Hour, tk.Actual())
+		assert.Equal(t, sat, tk.Sessions()[0].Start)
+		assert.Equal(t, cursor, tk.task.ActualUpdatedAt, "auto time tracking unaffected")
+		assert.Error(t, ts.LogActual(wt, 0, sat, sat.Add(time.Hour), 2*time.Hour, now), "duration longer than interval")
+		assert.Error(t, ts.LogActual(wt, 0, now, now.Add(time.Hour), time.Hour, now), "future")
+		assert.Error(t, tasks{NewTask()}.LogActual(wt, 0, sat, sat.Add(time.Hour), time.Hour, now), "never started")
+	})
+
+	t.Run("overlapping auto tracked time", func(t *testing.T) {
+		tk := getPausedTask()
+		other := getPausedTask()
+		other.task.Sessions = []Session{newSession(mon.Add(10*time.Hour), mon.Add(12*time.Hour), 2*time.Hour, 1, SessionSourceAuto)}
+		ts := tasks{tk, other}
+		assert.Error(t, ts.LogActual(wt, 0, mon.Add(11*time.Hour), mon.Add(13*time.Hour), 2*time.Hour, now))
+		assert.NoError(t, ts.LogActual(wt, 0, mon.Add(12*time.Hour), mon.Add(13*time.Hour), time.Hour, now), "adjacent")
+		assert.NoError(t, ts.LogActual(wt, 0, mon, mon.AddDate(0, 0, 1), 3*time.Hour, now), "fits alongside auto tracked time on the day")
+
+		started := getStartedTask()
+		started.task.ActualUpdatedAt = mon.Add(14 * time.Hour)
+		ts = append(ts, started)
+		assert.Error(t, ts.LogActual(wt, 0, mon.Add(15*time.Hour), mon.Add(16*time.Hour), time.Hour, now), "time not yet tracked on started task")
+	})
+
+	t.Run("log time on a day", func(t *testing.T) {
+		tk := getPausedTask()
+		tk.task.Sessions = []Session{newSession(mon.Add(9*time.Hour+30*time.Minute), mon.Add(17*time.Hour+30*time.Minute), 7*time.Hour+30*time.Minute, 1, SessionSourceAuto)}
+		other := getPausedTask()
+		other.task.Sessions = []Session{newSession(mon.Add(12*time.Hour+30*time.Minute), mon.Add(17*time.Hour+30*time.Minute), 5*time.Hour, 1, SessionSourceAuto)}
+		ts := tasks{tk, other}
+		assert.Error(t, ts.LogActualOnDay(wt, 0, mon, 4*time.Hour, now), "doesn't fit in working hours alongside other task's auto tracked time")
+		assert.NoError(t, ts.LogActualOnDay(wt, 0, mon, 3*time.Hour, now), "task's own auto tracked time isn't an overlap")
+		assert.Equal(t, 10*time.Hour+30*time.Minute, tk.Actual())
+		assert.NoError(t, ts.LogActualOnDay(wt, 0, sat, 10*time.Hour, now), "not limited to working hours on a weekend")
+		assert.Error(t, ts.LogActualOnDay(wt, 0, sat, 25*time.Hour, now), "longer than the day")
+	})
+}
+
+func TestCorrectActual(t *testing.T) {
+	wt := worktimes.GetAnonymousWorkTimes()
+	now := time.Now()
+	tk := getPausedTask()
+	tk.task.Sessions = []Session{
+		newSession(now.Add(-5*time.Hour), now.Add(-4*time.Hour), time.Hour, 1, SessionSourceAuto),
+		newSession(now.Add(-3*time.Hour), now.Add(-1*time.Hour), 2*time.Hour, 1, SessionSourceAuto),
+	}
+	ts := tasks{tk}
+
+	assert.NoError(t, ts.SubtractActual(wt, 0, 150*time.Minute, now))
+	assert.Equal(t, 30*time.Minute, tk.Actual())
+	assert.Equal(t, time.Duration(0), tk.Sessions()[1].Duration, "most recent session reduced first")
+	assert.Equal(t, 30*time.Minute, tk.Sessions()[0].Duration)
+	assert.Error(t, ts.SubtractActual(wt, 0, time.Hour, now), "actual can't be negative")
+	assert.Equal(t, 30*time.Minute, tk.Actual())
+
+	assert.NoError(t, ts.SetActual(wt, 0, 2*time.Hour, now))
+	assert.Equal(t, 2*time.Hour, tk.Actual())
+	assert.Equal(t, SessionSourceManual, tk.Sessions()[2].Source)
+	assert.NoError(t, ts.SetActual(wt, 0, 0, now))
+	assert.Equal(t, time.Duration(0), tk.Actual())
+	assert.Equal(t, 3, len(tk.ActualCorrections()), "each correction recorded")
+
+	assert.Error(t, tasks{NewTask()}.SetActual(wt, 0, time.Hour, now), "never started")
+}
+
+func TestWeights(t *testing.T) {
+	wt := worktimes.GetAnonymousWorkTimes()
+	// Monday 2018-01-01, 10am
+	mon := time.Date(2018, 1, 1, 10, 0, 0, 0, time.Local)
+	main := getStartedTask()
+	deploy := getStartedTask()
+	main.task.ActualUpdatedAt = mon
+	deploy.task.ActualUpdatedAt = mon
+	ts := tasks{main, deploy}
+	assert.Error(t, ts.SetWeight(wt, 1, 0, mon), "weight must be positive")
+	assert.Error(t, ts.SetWeight(wt, 1, math.NaN(), mon), "weight must be a number")
+	assert.Error(t, ts.SetWeight(wt, 1, math.Inf(1), mon), "weight must be finite")
+	assert.Equal(t, DefaultWeight, deploy.Weight())
+	assert.NoError(t, ts.SetWeight(wt, 1, 0.25, mon))
+	assert.Equal(t, 0.8, main.Share(ts))
+
+	work := func(from, to time.Duration) time.Duration {
+		return wt.DurationBetween(mon.Add(from), mon.Add(to))
+	}
+	autoAddActual(wt, ts, mon.Add(5*time.Hour))
+	assert.Equal(t, share(work(0, 5*time.Hour), 0.8), main.Actual())
+	assert.Equal(t, share(work(0, 5*time.Hour), 0.2), deploy.Actual())
+	assert.Equal(t, 0.2, deploy.Sessions()[0].Share)
+
+	// Reweighting tracks time up to now with the old weights.
+	assert.NoError(t, ts.SetWeight(wt, 1, DefaultWeight, mon.Add(6*time.Hour)))
+	before := share(work(0, 6*time.Hour), 0.8)
+	assert.Equal(t, before, main.Actual())
+	assert.Equal(t, DefaultWeight, deploy.Weight())
+	autoAddActual(wt, ts, mon.Add(7*time.Hour))
+	assert.Equal(t, before+share(work(6*time.Hour, 7*time.Hour), 0.5), main.Actual())
+}
+
+func TestCheckTime(t *testing.T) {
+	now := time.Now()
+	tk := getPausedTask()
+	tk.task.ActualUpdatedAt = now.Add(-2 * time.Hour)
+	tk.task.PausedAt = now.Add(-time.Hour)
+	assert.NoError(t, tk.CheckTime(now.Add(-time.Hour)))
+	assert.Error(t, tk.CheckTime(now.Add(-90*time.Minute)), "before paused")
+	tk.task.Sessions = []Session{newSession(now.Add(-time.Hour), now.Add(-30*time.Minute), 30*time.Minute, 1, SessionSourceManual)}
+	assert.Error(t, tk.CheckTime(now.Add(-45*time.Minute)), "before end of session")
+	assert.NoError(t, NewTask().CheckTime(now.Add(-time.Hour)), "new task has no activity")
+}
@@ -0,0 +1,302 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// undoMaxEntries is the max number of changes that can be undone. Older
+// changes are dropped so that the undo file doesn't grow without bound.
+const undoMaxEntries = 50
+
+// undoFile is the undo history for one estfile. It's stored in a sidecar
+// file so that undo works across invocations of est.
+type undoFile struct {
+	Undo []undoEntry // changes which can be undone, most recent last
+	Redo []undoEntry // changes which were undone and can be redone, most recent last
+}
+
+// undoEntry is one change to an estfile which may be undone or redone. An
+// entry holds only what differs between the estfile before and after the
+// change, so that the undo file stays small for large estfiles, see apply().
+type undoEntry struct {
+	Description string    // description of the command which made this change, e.g. "est done 3c"
+	When        time.Time // time at which the command made this change
+	// Header holds the estfile's fields other than its tasks, e.g. fake
+	// ratios, to restore when this entry is undone (or redone), if they
+	// changed. Tasks are the tasks to restore, i.e. tasks which were changed
+	// or removed, and Removed the IDs of tasks to remove, i.e. tasks which
+	// were added.
+	Header  *estFile   `toml:",omitempty"`
+	Tasks   []undoTask `toml:",omitempty"`
+	Removed []string   `toml:",omitempty"`
+	// Archive are the IDs of tasks to move from the estfile to the archive
+	// file when this entry is undone (or redone), and Unarchive the IDs of
+	// tasks to remove from the archive file, see EstFile.Archive().
+	Archive   []string `toml:",omitempty"`
+	Unarchive []string `toml:",omitempty"`
+	// Auto is true iff this change was made automatically by est, see
+	// EstFile.WriteAuto(). Automatic changes are grouped with the change
+	// made by the preceding command, see undoStepLen().
+	Auto bool `toml:",omitempty"`
+}
+
+// undoTask is a task to restore at its index in the restored estfile.
+type undoTask struct {
+	Index int
+	Task  task
+}
+
+// setRestore sets this entry to restore passed restore estfile when applied
+// to passed cur estfile, see apply().
+func (e *undoEntry) setRestore(cur, restore estFile) {
+	curByID := make(map[string]int, len(cur.Tasks))
+	for i, t := range cur.Tasks {
+		curByID[t.ID.String()] = i
+	}
+	e.Tasks, e.Removed, e.Header = nil, nil, nil
+	for i, t := range restore.Tasks {
+		j, ok := curByID[t.ID.String()]
+		delete(curByID, t.ID.String())
+		if ok && encodeTask(t) == encodeTask(cur.Tasks[j]) {
+			continue
+		}
+		e.Tasks = append(e.Tasks, undoTask{Index: i, Task: t})
+	}
+	for _, t := range cur.Tasks {
+		if _, ok := curByID[t.ID.String()]; ok {
+			e.Removed = append(e.Removed, t.ID.String())
+		}
+	}
+	if h := restore.header(); encodeEstFile(h) != encodeEstFile(cur.header()) {
+		e.Header = &h
+	}
+}
+
+// restoresEstfile returns true iff applying this entry changes an estfile.
+func (e undoEntry) restoresEstfile() bool {
+	return e.Header != nil || len(e.Tasks) > 0 || len(e.Removed) > 0
+}
+
+// apply returns passed cur estfile with this entry's changes restored. Tasks
+// unchanged by this entry keep their order.
+func (e undoEntry) apply(cur estFile) estFile {
+	restored := cur
+	if e.Header != nil {
+		restored.Version = e.Header.Version
+		restored.FakeHistoricalEstimateAccuracyRatios = e.Header.FakeHistoricalEstimateAccuracyRatios
+		restored.ArchivedEstimateAccuracyRatios = e.Header.ArchivedEstimateAccuracyRatios
+	}
+	skip := make(map[string]bool, len(e.Tasks)+len(e.Removed))
+	for _, id := range e.Removed {
+		skip[id] = true
+	}
+	for _, ut := range e.Tasks {
+		skip[ut.Task.ID.String()] = true
+	}
+	ts := make([]task, 0, len(cur.Tasks)+len(e.Tasks))
+	for _, t := range cur.Tasks {
+		if !skip[t.ID.String()] {
+			ts = append(ts, t)
+		}
+	}
+	for _, ut := range e.Tasks {
+		i := ut.Index
+		if i > len(ts) {
+			i = len(ts)
+		}
+		ts = append(ts, task{})
+		copy(ts[i+1:], ts[i:])
+		ts[i] = ut.Task
+	}
+	restored.Tasks = ts
+	return restored
+}
+
+func (e undoEntry) render() string {
+	return fmt.Sprintf("%s (%s)", e.Description, e.When.Local().Format("Mon Jan 2 3:04pm"))
+}
+
+// Undo reverts this EstFile to its state prior to the passed number of most
+// recent changes, and writes it. Automatic changes made since the last of
+// those changes are also undone, see WriteAuto(). Returns a description of
+// each undone change.
+func (ef *EstFile) Undo(n int) ([]string, error) {
+	return ef.undoOrRedo(n, "undo", true, func(u *undoFile) (*[]undoEntry, *[]undoEntry) {
+		return &u.Undo, &u.Redo
+	})
+}
+
+// Redo reapplies the passed number of most recently undone changes to this
+// EstFile, along with the automatic changes undone with them, and writes it.
+// Returns a description of each redone change.
+func (ef *EstFile) Redo(n int) ([]string, error) {
+	return ef.undoOrRedo(n, "redo", false, func(u *undoFile) (*[]undoEntry, *[]undoEntry) {
+		return &u.Redo, &u.Undo
+	})
+}
+
+// undoStepLen returns the number of entries at the top of passed stack which
+// are undone or redone as one change: a command's change and the automatic
+// changes made after it. On the undo stack, automatic entries are above
+// their command's entry, and on the redo stack, below it.
+func undoStepLen(stack []undoEntry, autoAbove bool) int {
+	top := len(stack) - 1
+	n := 1
+	if autoAbove {
+		for n <= top && stack[top-n+1].Auto {
+			n++
+		}
+		return n
+	}
+	if !stack[top].Auto {
+		for n <= top && stack[top-n].Auto {
+			n++
+		}
+	}
+	return n
+}
+
+// undoOrRedo pops n changes from one stack of passed undoFile and pushes the
+// inverse of each entry onto the other stack, restoring the last popped
+// estfile. Each change is one or more entries, see undoStepLen().
+func (ef *EstFile) undoOrRedo(n int, verb string, autoAbove bool, stacks func(u *undoFile) (from *[]undoEntry, to *[]undoEntry)) ([]string, error) {
+	if ef.storage == nil {
+		return nil, errors.New("estFile.storage was nil")
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("number of changes to %s must be at least 1", verb)
+	}
+	undoFileName := sidecarFileName(ef.storage.FileName(), "undo")
+	var u undoFile
+	if err := readSidecar(undoFileName, &u); err != nil {
+		return nil, err
+	}
+	from, to := stacks(&u)
+	entries := 0
+	for i := 0; i < n; i++ {
+		if entries == len(*from) {
+			return nil, fmt.Errorf("there are only %d changes to %s", i, verb)
+		}
+		entries += undoStepLen((*from)[:len(*from)-entries], autoAbove)
+	}
+	cur := toUnexportedEstfile(*ef)
+	archiveFileName := sidecarFileName(ef.storage.FileName(), "archive")
+	var af *archiveFile
+	ds := make([]string, entries)
+	for i := 0; i < entries; i++ {
+		e := (*from)[len(*from)-1]
+		*from = (*from)[:len(*from)-1]
+		if len(e.Archive) > 0 || len(e.Unarchive) > 0 {
+			if af == nil {
+				af2, err := getArchiveFile(archiveFileName)
+				if err != nil {
+					return nil, err
+				}
+				af = &af2
+			}
+			af.remove(e.Unarchive)
+			af.add(tasksWithIDs(cur.Tasks, e.Archive))
+		}
+		restored := e.apply(cur)
+		inverse := undoEntry{Description: e.Description, When: e.When, Archive: e.Unarchive, Unarchive: e.Archive, Auto: e.Auto}
+		inverse.setRestore(restored, cur)
+		*to = append(*to, inverse)
+		cur = restored
+		ds[i] = e.render()
+	}
+	if err := writeSidecar(undoFileName, u); err != nil {
+		return nil, err
+	}
+	if af != nil {
+		if err := writeSidecar(archiveFileName, *af); err != nil {
+			return nil, err
+		}
+	}
+	loaded := ef.loaded
+	*ef = toExportedEstfile(cur)
+	ef.loaded = loaded
+	return ds, ef.write()
+}
+
+// tasksWithIDs returns the tasks of passed tasks with passed IDs.
+func tasksWithIDs(ts []task, ids []string) []task {
+	want := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+	var ts2 []task
+	for _, t := range ts {
+		if want[t.ID.String()] {
+			ts2 = append(ts2, t)
+		}
+	}
+	return ts2
+}
+
+// pushUndo records that the passed estfile is about to change, such that
+// this change can be undone by restoring passed entry.
+func pushUndo(estFileName string, e undoEntry) error {
+	undoFileName := sidecarFileName(estFileName, "undo")
+	var u undoFile
+	if err := readSidecar(undoFileName, &u); err != nil {
+		return err
+	}
+	u.Undo = append(u.Undo, e)
+	if len(u.Undo) > undoMaxEntries {
+		u.Undo = u.Undo[len(u.Undo)-undoMaxEntries:]
+	}
+	u.Redo = nil // a new change invalidates undone changes, like a text editor
+	return writeSidecar(undoFileName, u)
+}
+
+// RenderChangedTasks returns a user-suitable summary of tasks which differ
+// between the passed before and after estfiles.
+func RenderChangedTasks(before, after EstFile) string {
+	beforeByID := make(map[string]*Task, len(before.Tasks))
+	for _, t := range before.Tasks {
+		beforeByID[t.ID().String()] = t
+	}
+	var changed tasks
+	for _, t := range after.Tasks {
+		t2, ok := beforeByID[t.ID().String()]
+		delete(beforeByID, t.ID().String())
+		if ok && encodeTask(t.task) == encodeTask(t2.task) {
+			continue
+		}
+		changed = append(changed, t)
+	}
+	var removed tasks
+	for _, t := range before.Tasks {
+		if _, ok := beforeByID[t.ID().String()]; ok {
+			removed = append(removed, t)
+		}
+	}
+	var rs []string
+	for i := range changed {
+		rs = append(rs, RenderTaskOneLineSummary(changed[i], i == 0))
+	}
+	if len(removed) > 0 {
+		rs = append(rs, "Removed:")
+		for i := range removed {
+			rs = append(rs, RenderTaskOneLineSummary(removed[i], i == 0))
+		}
+	}
+	rs = append(rs, "") // causes the Join to add an extra newline
+	return strings.Join(rs, "\n")
+}
+
+// encodeTask returns a canonical encoding of passed task, so that tasks can
+// be compared independent of e.g. time.Location pointers.
+func encodeTask(t task) string {
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(struct{ Task task }{t}); err != nil {
+		panic(fmt.Errorf("encodeTask failed: %s", err))
+	}
+	return buf.String()
+}
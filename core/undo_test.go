@@ -0,0 +1,130 @@
+package core
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUndoRedo(t *testing.T) {
+	ef, cleanup := newTestEstFile(t)
+	defer cleanup()
+	dir := filepath.Dir(ef.storage.FileName())
+
+	for _, name := range []string{"first", "second"} {
+		tk := NewTask()
+		assert.NoError(t, tk.SetName(name))
+		ef.Tasks = append(ef.Tasks, tk)
+		assert.NoError(t, ef.Write("est add "+name))
+	}
+	assert.Equal(t, dir+"/estfile.undo.toml", sidecarFileName(ef.storage.FileName(), "undo"))
+
+	ds, err := ef.Undo(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ds))
+	assert.Equal(t, 1, len(ef.Tasks), "second task was undone")
+	assert.Equal(t, "first", ef.Tasks[0].Name())
+
+	_, err = ef.Undo(2)
+	assert.Error(t, err, "only one change remains to undo")
+
+	_, err = ef.Redo(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ef.Tasks), "second task was redone")
+
+	ef2, err := getEstFile(ef.storage)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ef2.Tasks), "redo was written")
+
+	_, err = ef.Undo(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(ef.Tasks), "both tasks undone")
+	tk := NewTask()
+	assert.NoError(t, tk.SetName("third"))
+	ef.Tasks = append(ef.Tasks, tk)
+	assert.NoError(t, ef.Write("est add third"))
+	_, err = ef.Redo(1)
+	assert.Error(t, err, "a new change discards redo history")
+}
+
+func TestUndoEntryHoldsChangesOnly(t *testing.T) {
+	ef, cleanup := newTestEstFile(t)
+	defer cleanup()
+	a, b, c := NewTask(), NewTask(), NewTask()
+	ef.Tasks = tasks{a, b, c}
+	assert.NoError(t, ef.Write("est add"))
+	assert.NoError(t, b.SetName("renamed"))
+	ef.Tasks = tasks{b, c}
+	assert.NoError(t, ef.Write("est rename"))
+
+	var u undoFile
+	assert.NoError(t, readSidecar(sidecarFileName(ef.storage.FileName(), "undo"), &u))
+	e := u.Undo[len(u.Undo)-1]
+	assert.Equal(t, 2, len(e.Tasks), "only the renamed and removed tasks are saved")
+	assert.Equal(t, 0, len(e.Removed))
+	assert.Nil(t, e.Header, "header unchanged")
+
+	_, err := ef.Undo(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(ef.Tasks))
+	assert.Equal(t, a.ID(), ef.Tasks[0].ID(), "restored tasks keep their order")
+	assert.Equal(t, b.ID(), ef.Tasks[1].ID())
+	assert.Equal(t, "", ef.Tasks[1].Name())
+	_, err = ef.Redo(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ef.Tasks))
+	assert.Equal(t, "renamed", ef.Tasks[0].Name())
+}
+
+func TestUndoWriteWithoutUndo(t *testing.T) {
+	ef, cleanup := newTestEstFile(t)
+	defer cleanup()
+	tk := NewTask()
+	ef.Tasks = tasks{tk}
+	assert.NoError(t, ef.Write("est add"))
+	assert.NoError(t, tk.SetName("first"))
+	assert.NoError(t, ef.Write("est focus"))
+	assert.NoError(t, ef.Reload())
+	assert.NoError(t, ef.Tasks[0].SetName("second"))
+	assert.NoError(t, ef.WriteWithoutUndo())
+
+	ds, err := ef.Undo(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ds))
+	assert.Equal(t, "", ef.Tasks[0].Name(), "later writes undone along with the first")
+	_, err = ef.Redo(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", ef.Tasks[0].Name())
+}
+
+func TestUndoAfterAutomaticChange(t *testing.T) {
+	ef, cleanup := newTestEstFile(t)
+	defer cleanup()
+	tk := NewTask()
+	ef.Tasks = tasks{tk}
+	assert.NoError(t, ef.Write("est add"))
+	tk.task.ActualUpdatedAt = time.Now()
+	assert.NoError(t, ef.Write("est start"))
+	tk.task.IsPaused = true
+	assert.NoError(t, ef.WriteAuto("pause idle tasks"))
+
+	ds, err := ef.Undo(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ds), "idle pause undone along with 'est start'")
+	assert.True(t, strings.HasPrefix(ds[0], "pause idle tasks"))
+	assert.True(t, strings.HasPrefix(ds[1], "est start"))
+	assert.False(t, ef.Tasks[0].IsStarted())
+
+	ds, err = ef.Redo(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ds), "idle pause redone along with 'est start'")
+	assert.True(t, ef.Tasks[0].IsPaused())
+	_, err = ef.Undo(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(ef.Tasks))
+	_, err = ef.Undo(1)
+	assert.Error(t, err, "nothing left to undo")
+}
@@ -21,6 +21,7 @@ type WorkTimes interface {
 	GetWorkTimesOnDay(day time.Time) []time.Time
 	DurationBetween(start, end time.Time) time.Duration
 	TimeAfter(start time.Time, d time.Duration) time.Time
+	Location() *time.Location
 }
 
 type workTimes struct {
@@ -31,11 +32,21 @@ type workTimes struct {
 	workHours []time.Time // workHours must be even length with monotonically increasing time of day. This is enforced during construction. Only hour and minute of these times are defined: the hour and minute are used to construct specific workdays in GetWorkTimesOnDay().
 	// TODO if we wanted to support different working hours for different days of the week, this is achievable by making workhours []string --> map[time.Weekday]string
 	// TODO Calendar has a pretty nifty holidays interface: support holidays, vacation
+
+	// location is the timezone of workHours and of workdays' boundaries, so
+	// that working hours don't shift if the machine's timezone changes.
+	location *time.Location
+}
+
+// Location returns the timezone in which working hours are defined.
+func (wt *workTimes) Location() *time.Location {
+	return wt.location
 }
 
-// returns working start/end times on the day of the passed time, nil if passed time isn't a workday. Guaranteed that len([]time.Time) % 2 == 0, and that these times have monotonically increasing hour:minute in local time on day of passed time.
+// returns working start/end times on the day of the passed time, nil if passed time isn't a workday. Guaranteed that len([]time.Time) % 2 == 0, and that these times have monotonically increasing hour:minute in wt's location on day of passed time.
 // TODO real doc and unit test
 func (wt *workTimes) GetWorkTimesOnDay(t time.Time) []time.Time {
+	t = t.In(wt.location)
 	if !wt.calendar.IsWorkday(t) {
 		return nil
 	}
@@ -47,12 +58,12 @@ func (wt *workTimes) DurationBetween(start, end time.Time) time.Duration {
 	if end.Before(start) {
 		return wt.DurationBetween(end, start)
 	}
-	// Business hours are relative to a specific timezone; we assume local time.
-	if start.Location() != time.Local {
-		return wt.DurationBetween(start.Local(), end)
+	// Business hours are relative to a specific timezone, see New().
+	if start.Location() != wt.location {
+		return wt.DurationBetween(start.In(wt.location), end)
 	}
-	if end.Location() != time.Local {
-		return wt.DurationBetween(start, end.Local())
+	if end.Location() != wt.location {
+		return wt.DurationBetween(start, end.In(wt.location))
 	}
 	// fmt.Printf("DurationBetween start=%v end=%v\n", start, end)
 	d := time.Duration(0) // accumulated business hours between start and end
@@ -74,9 +85,9 @@ func (wt *workTimes) TimeAfter(start time.Time, d time.Duration) time.Time {
 	if d < 0 {
 		panic(fmt.Sprintf("negative duration unsupported: %v", d))
 	}
-	// Business hours are relative to a specific timezone; we assume local time.
-	if start.Location() != time.Local {
-		return wt.TimeAfter(start.Local(), d)
+	// Business hours are relative to a specific timezone, see New().
+	if start.Location() != wt.location {
+		return wt.TimeAfter(start.In(wt.location), d)
 	}
 	low := start
 	high := start.Add(time.Hour * 24 * 365 * 100) // 100 years in future; algorithm will never terminate if true result is more than 100 years in future.
@@ -97,7 +108,17 @@ func (wt *workTimes) TimeAfter(start time.Time, d time.Duration) time.Time {
 	}
 }
 
+// New returns WorkTimes with passed workdays and workhours in local time.
+// See NewInLocation().
 func New(workdays map[time.Weekday]bool, workhours []string) (WorkTimes, error) {
+	return NewInLocation(workdays, workhours, time.Local)
+}
+
+// NewInLocation returns WorkTimes with passed workdays and workhours in the
+// passed timezone. Days and hours are determined in that timezone regardless
+// of the timezone of times passed to WorkTimes, e.g. 9:30am in New York is
+// 2:30pm in London.
+func NewInLocation(workdays map[time.Weekday]bool, workhours []string, loc *time.Location) (WorkTimes, error) {
 	ts, err := parseWorkHours(workhours)
 	if err != nil {
 		return nil, fmt.Errorf("new WorkTimes failed: %s", err.Error())
@@ -113,7 +134,7 @@ func New(workdays map[time.Weekday]bool, workhours []string) (WorkTimes, error)
 	for workday, isWorkday := range workdays {
 		c.SetWorkday(workday, isWorkday)
 	}
-	return &workTimes{calendar: c, workHours: ts}, nil
+	return &workTimes{calendar: c, workHours: ts, location: loc}, nil
 }
 
 // TODO doc, unit test, maybe rename
@@ -54,3 +54,24 @@ func TestBusinessHoursBetweenTimes(t *testing.T) {
 		})
 	}
 }
+
+func TestNewInLocation(t *testing.T) {
+	loc := time.FixedZone("EST", -5*60*60)
+	wt, err := NewInLocation(map[time.Weekday]bool{time.Monday: true}, []string{"9:30am", "12:00pm"}, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Monday 2018-01-01 9:30am-noon EST is 2:30pm-5pm UTC.
+	start := time.Date(2018, 1, 1, 14, 30, 0, 0, time.UTC)
+	if d := wt.DurationBetween(start, start.Add(5*time.Hour)); d != 150*time.Minute {
+		t.Errorf("expected working hours in EST, got %v", d)
+	}
+	// Tuesday 2am UTC is Monday 9pm EST.
+	wts := wt.GetWorkTimesOnDay(time.Date(2018, 1, 2, 2, 0, 0, 0, time.UTC))
+	if len(wts) != 2 || !wts[0].Equal(start) {
+		t.Errorf("expected Monday's working hours in EST, got %v", wts)
+	}
+	if wt.Location() != loc {
+		t.Errorf("expected location %v, got %v", loc, wt.Location())
+	}
+}
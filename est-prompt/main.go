@@ -1,15 +1,31 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/ryanberckmans/est/core"
 )
 
 func main() {
+	var o core.ConfigOverrides
+	flag.StringVar(&o.ConfigFile, "config", "", "use this estconfig file")
+	flag.StringVar(&o.Estfile, "estfile", "", "use this estfile")
+	flag.StringVar(&o.Profile, "profile", "", "use this profile from estconfig")
+	flag.Parse()
+	core.SetConfigOverrides(o)
 	core.WithEstConfigAndFile(func(ec *core.EstConfig, ef *core.EstFile) {
 		ts := ef.Tasks.IsNotDeleted().IsStarted().SortByStartedAtDescending()
 		os.Stdout.WriteString(renderPrompt(ts))
+		if ec.Idle != core.IdleOff {
+			// est-prompt runs on every shell prompt, so it records shell
+			// activity, see 'est help idle'.
+			if err := ef.Heartbeat(ec.WorkTimes(), ec.IdleThreshold(), time.Now()); err != nil {
+				fmt.Fprintf(os.Stderr, "est-prompt: couldn't record shell activity: %s\n", err)
+			}
+		}
 	}, func() {
 		// failed to load estconfig or estfile
 		os.Stdout.WriteString(promptFailed)
@@ -31,6 +31,9 @@ const promptFailed = bashOpen + ansiDangerOrange + bashClose + "est-prompt faile
 // The rendering aims to be minimally distracting by being fixed width, commas always
 // in same char position, and same color; and maximally useful, currently by showing
 // an adaptive short form of task names.
+// When started tasks have different focus weights, each named task's share
+// of time is shown as a percentage, in the same width: both tasks when two
+// are started, otherwise only the first task.
 func renderPrompt(ts []*core.Task) string {
 	switch len(ts) {
 	case 0:
@@ -38,12 +41,41 @@ func renderPrompt(ts []*core.Task) string {
 	case 1:
 		return promptColor(promptOneTask(ts[0]))
 	case 2:
+		if isWeighted(ts) {
+			return promptColor(promptTwoWeightedTasks(ts[0], ts[1], ts))
+		}
 		return promptColor(promptTwoTasks(ts[0], ts[1]))
 	default:
+		if isWeighted(ts) {
+			return promptColor(promptNWeightedTasks(ts[0], ts[1], ts[2], ts[3:], ts))
+		}
 		return promptColor(promptNTasks(ts[0], ts[1], ts[2], ts[3:]))
 	}
 }
 
+// isWeighted returns true iff passed tasks don't all have the same weight.
+func isWeighted(ts []*core.Task) bool {
+	for _, t := range ts {
+		if t.Weight() != ts[0].Weight() {
+			return true
+		}
+	}
+	return false
+}
+
+// promptPercent returns passed task's share of time among passed started
+// tasks, as a percentage which fits in two digits.
+func promptPercent(t *core.Task, ts []*core.Task) int {
+	p := int(t.Share(ts)*100 + 0.5)
+	if p > 99 {
+		return 99
+	}
+	if p < 1 {
+		return 1
+	}
+	return p
+}
+
 func promptColor(s string) string {
 	return bashOpen + ansiReset + ansiBold + ansiBoldYellow + bashClose + s + bashOpen + ansiReset + bashClose
 }
@@ -65,6 +97,18 @@ func promptNTasks(t *core.Task, t2 *core.Task, _ *core.Task, ts []*core.Task) st
 	return fmt.Sprintf("%10s, %-8s+%d", s, s2, 1+len(ts))
 }
 
+func promptTwoWeightedTasks(t *core.Task, t2 *core.Task, ts []*core.Task) string {
+	s := stringsShortForm(4, 6, strings.Fields(getTaskNameForPrompt(t)))
+	s2 := stringsShortForm(4, 6, strings.Fields(getTaskNameForPrompt(t2)))
+	return fmt.Sprintf("%6s %2d%%, %-6s %2d%%", s, promptPercent(t, ts), s2, promptPercent(t2, ts))
+}
+
+func promptNWeightedTasks(t *core.Task, t2 *core.Task, _ *core.Task, rest []*core.Task, ts []*core.Task) string {
+	s := stringsShortForm(4, 6, strings.Fields(getTaskNameForPrompt(t)))
+	s2 := stringsShortForm(4, 8, strings.Fields(getTaskNameForPrompt(t2)))
+	return fmt.Sprintf("%6s %2d%%, %-8s+%d", s, promptPercent(t, ts), s2, 1+len(rest))
+}
+
 func getTaskNameForPrompt(t *core.Task) string {
 	// TODO t.ShortName is used if non-empty; maybe t.Summary instead; or t.Tags
 	return t.Name()